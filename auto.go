@@ -0,0 +1,105 @@
+package dht
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Sensor types detected by ReadAuto per pin.
+var detectedTypes = struct {
+	sync.Mutex
+	m map[int]detectedType
+}{m: make(map[int]detectedType)}
+
+type detectedType struct {
+	sensorType SensorType
+	// Failed reads in a row since detection.
+	failures int
+}
+
+// Failed reads in a row, after which sensor type is detected again,
+// since sensor might be replaced.
+const maxDetectedFailures = 3
+
+// Retries made by ReadAuto.
+const autoRetries = 3
+
+// Start signal used to detect sensor type: long enough to wake up DHT11,
+// but still within 0.8-20 milliseconds AM2302 accept.
+const detectStartLow = 18 * time.Millisecond
+
+// Read sensor connected to pin, detecting its type, with retries and
+// waiting out minimum interval between reads: the simplest way to see
+// temperature and humidity. Detected type is remembered per pin and
+// detected again after few failed reads in a row.
+// GPIO is closed after read, as ReadDHTxx does.
+// Use Read or NewSensor with options to tune reads.
+func ReadAuto(pin int) (Reading, error) {
+	return ReadAutoContext(context.Background(), pin)
+}
+
+// Same as ReadAuto, but stop as soon as ctx is done.
+func ReadAutoContext(ctx context.Context, pin int) (Reading, error) {
+	opts := []Option{WithRetries(autoRetries), WithMinIntervalWait()}
+	sensorType, ok := lookupDetectedType(pin)
+	var sensor *Sensor
+	var err error
+	if ok {
+		sensor, err = NewSensor(sensorType, pin, opts...)
+	} else {
+		// DHT22 settings are safe for both types, apart from start signal
+		sensor, err = NewSensor(DHT22, pin,
+			append(opts, WithStartSignal(detectStartLow))...)
+	}
+	if err != nil {
+		return Reading{}, err
+	}
+	defer sensor.Close()
+	sensor.detect = !ok
+	reading, _, err := sensor.readWithRetry(ctx)
+	recordDetectedType(pin, sensor.sensorType, !sensor.detect, err)
+	return reading, err
+}
+
+func lookupDetectedType(pin int) (SensorType, bool) {
+	detectedTypes.Lock()
+	defer detectedTypes.Unlock()
+	detected, ok := detectedTypes.m[pin]
+	return detected.sensorType, ok
+}
+
+// Remember sensor type of pin, if it's known, and count failed reads.
+// Forget type after too many failures.
+func recordDetectedType(pin int, sensorType SensorType, known bool,
+	err error) {
+	detectedTypes.Lock()
+	defer detectedTypes.Unlock()
+	detected, ok := detectedTypes.m[pin]
+	if !ok {
+		if !known {
+			return
+		}
+		detected = detectedType{sensorType: sensorType}
+	}
+	if err == nil {
+		detected.failures = 0
+	} else {
+		detected.failures++
+	}
+	if detected.failures >= maxDetectedFailures {
+		delete(detectedTypes.m, pin)
+		return
+	}
+	detectedTypes.m[pin] = detected
+}
+
+// Guess sensor type from 5 bytes of response. DHT22 humidity word
+// never exceed 1000 (100%), so its high byte is below 4, while DHT11
+// can't measure humidity below 20%, which it report in first byte.
+func sensorTypeFromBytes(b [5]byte) SensorType {
+	if b[0] >= 4 {
+		return DHT11
+	}
+	return DHT22
+}
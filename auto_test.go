@@ -0,0 +1,87 @@
+package dht
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSensorTypeFromBytes(t *testing.T) {
+	tests := []struct {
+		b    [5]byte
+		want SensorType
+	}{
+		// DHT11: 45% 23.4°C, 20% 0°C, 95% -5°C
+		{[5]byte{45, 0, 23, 4}, DHT11},
+		{[5]byte{20, 0, 0, 0}, DHT11},
+		{[5]byte{95, 0, 5, 0x80}, DHT11},
+		// DHT22: 45.2% 21.3°C, 100% -40°C, 0% 80°C
+		{dht22Bytes(452, 213), DHT22},
+		{dht22Bytes(1000, -400), DHT22},
+		{dht22Bytes(0, 800), DHT22},
+	}
+	for _, test := range tests {
+		if got := sensorTypeFromBytes(test.b); got != test.want {
+			t.Errorf("%v: got %v, want %v", test.b, got, test.want)
+		}
+	}
+}
+
+func TestReadAuto(t *testing.T) {
+	installFakeClock(t)
+	dht11 := [5]byte{45, 0, 23, 4, 72}
+	first := newMockPin(responseWave(dht11), 1)
+	second := newMockPin(responseWave(dht11), 1)
+	second.n = first.n
+	var dead []*mockPin
+	for i := 0; i < maxDetectedFailures; i++ {
+		pin := newMockPin(nil, 1)
+		pin.n = first.n
+		dead = append(dead, pin)
+	}
+	installFakeGPIO(t, append([]*mockPin{first, second}, dead...)...)
+	pin := first.N()
+
+	for i := 0; i < 2; i++ {
+		reading, err := ReadAuto(pin)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reading.Sensor != DHT11 || reading.Temperature != 23.4 ||
+			reading.Humidity != 45 {
+			t.Errorf("read %d: got %v %v°C %v%%, want DHT11 23.4°C 45%%", i,
+				reading.Sensor, reading.Temperature, reading.Humidity)
+		}
+		if sensorType, ok := lookupDetectedType(pin); !ok ||
+			sensorType != DHT11 {
+			t.Errorf("read %d: detected %v, %v, want DHT11", i, sensorType, ok)
+		}
+	}
+
+	// Type is detected again, once sensor fails few times in a row
+	for i := 0; i < maxDetectedFailures; i++ {
+		if _, ok := lookupDetectedType(pin); !ok {
+			t.Fatalf("type forgotten after %d failures", i)
+		}
+		if _, err := ReadAuto(pin); !errors.Is(err, ErrNoResponse) {
+			t.Errorf("got %v, want %v", err, ErrNoResponse)
+		}
+	}
+	if _, ok := lookupDetectedType(pin); ok {
+		t.Errorf("type remembered after %d failures", maxDetectedFailures)
+	}
+}
+
+func TestReadAutoDetectionFailure(t *testing.T) {
+	installFakeClock(t)
+	pin := newMockPin(nil, 1)
+	installFakeGPIO(t, pin)
+	if _, err := ReadAuto(pin.N()); !errors.Is(err, ErrNoResponse) {
+		t.Errorf("got %v, want %v", err, ErrNoResponse)
+	}
+	if _, ok := lookupDetectedType(pin.N()); ok {
+		t.Error("type remembered without valid response")
+	}
+	if n := pin.count("Write") / 2; n != autoRetries+1 {
+		t.Errorf("%d captures, want %d", n, autoRetries+1)
+	}
+}
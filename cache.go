@@ -0,0 +1,105 @@
+package dht
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// CachedSensor serve last successful reading while it is younger
+// than TTL and read sensor only when cached reading expired.
+// Concurrent callers share single sensor read in flight.
+type CachedSensor struct {
+	sensor *Sensor
+	ttl    time.Duration
+
+	mu       sync.Mutex
+	last     Reading
+	hasLast  bool
+	inflight *cachedCall
+}
+
+// Sensor read shared by concurrent callers.
+type cachedCall struct {
+	done    chan struct{}
+	reading Reading
+	err     error
+}
+
+// Wrap sensor with cache keeping readings for ttl,
+// which should be positive.
+func NewCachedSensor(sensor *Sensor, ttl time.Duration) (*CachedSensor, error) {
+	if ttl <= 0 {
+		return nil, fmt.Errorf("%w: cache ttl should be positive: %v",
+			ErrInvalidOption, ttl)
+	}
+	return &CachedSensor{sensor: sensor, ttl: ttl}, nil
+}
+
+// Return cached reading, if it isn't expired, otherwise read sensor.
+// If sensor can't be read again yet, expired reading is returned
+// along with ErrTooSoon, so caller decides whether it's still useful.
+func (this *CachedSensor) Read() (Reading, error) {
+	return this.ReadContext(context.Background())
+}
+
+// Same as Read, but stop waiting as soon as ctx is done. Sensor read
+// in flight is made with context of the caller which started it.
+func (this *CachedSensor) ReadContext(ctx context.Context) (Reading, error) {
+	this.mu.Lock()
+	if reading, ok := this.cached(this.ttl); ok {
+		this.mu.Unlock()
+		return reading, nil
+	}
+	if call := this.inflight; call != nil {
+		this.mu.Unlock()
+		select {
+		case <-call.done:
+			return call.reading, call.err
+		case <-ctx.Done():
+			return Reading{}, contextError(ctx)
+		}
+	}
+	call := &cachedCall{done: make(chan struct{})}
+	this.inflight = call
+	this.mu.Unlock()
+
+	call.reading, call.err = this.sensor.ReadContext(ctx)
+
+	this.mu.Lock()
+	this.inflight = nil
+	if call.err == nil {
+		this.last, this.hasLast = call.reading, true
+	} else if errors.Is(call.err, ErrTooSoon) {
+		// Sensor can't be read yet, expired reading is the best we have
+		if reading, ok := this.cached(-1); ok {
+			call.reading = reading
+		}
+	}
+	this.mu.Unlock()
+	close(call.done)
+	return call.reading, call.err
+}
+
+// Return cached reading marked as stale, if it is younger than ttl
+// (negative ttl means any age). Caller should hold the lock.
+func (this *CachedSensor) cached(ttl time.Duration) (Reading, bool) {
+	if !this.hasLast {
+		return Reading{}, false
+	}
+	age := time.Since(this.last.Time)
+	if ttl >= 0 && age >= ttl {
+		return Reading{}, false
+	}
+	reading := this.last
+	reading.Stale = true
+	reading.Age = age
+	return reading, true
+}
+
+// Close underlying sensor.
+func (this *CachedSensor) Close() error {
+	return this.sensor.Close()
+}
@@ -0,0 +1,110 @@
+package dht
+
+import (
+	"fmt"
+	"math"
+)
+
+// Humidity measured by sensor against known reference,
+// such as saturated salt solution (75.3% NaCl, 32.8% MgCl2).
+type CalibrationPoint struct {
+	// Reference relative humidity in percent.
+	Reference float32 `json:"reference"`
+	// Average humidity reported by sensor.
+	Measured float32 `json:"measured"`
+	// Number of readings averaged.
+	Samples int `json:"samples"`
+}
+
+// Humidity calibration collected from reference points. Solve fits
+// correction applied with WithCalibration. Calibration is marshaled
+// to JSON as is, so it could be persisted and loaded later.
+type Calibration struct {
+	Points []CalibrationPoint `json:"points"`
+}
+
+// Record average raw humidity of readings taken against reference
+// humidity in percent. Readings should be stable (see HumidityStable).
+func (this *Calibration) AddReferencePoint(referenceRH float32,
+	readings []Reading) error {
+	if referenceRH <= 0 || referenceRH > 100 {
+		return fmt.Errorf("%w: reference humidity out of range: %v",
+			ErrCalibration, referenceRH)
+	}
+	if len(readings) == 0 {
+		return fmt.Errorf("%w: no readings for reference %v%%",
+			ErrCalibration, referenceRH)
+	}
+	mean, _ := humidityStats(readings)
+	this.Points = append(this.Points, CalibrationPoint{Reference: referenceRH,
+		Measured: float32(mean), Samples: len(readings)})
+	return nil
+}
+
+// Fit linear correction reference = measured*scale + offset by least
+// squares, which is exact for two points. At least two points with
+// different measured humidity are required.
+func (this *Calibration) Solve() (offset, scale float32, err error) {
+	n := float64(len(this.Points))
+	if n < 2 {
+		return 0, 0, fmt.Errorf("%w: %d reference points, need at least 2",
+			ErrCalibration, len(this.Points))
+	}
+	var meanX, meanY float64
+	for _, p := range this.Points {
+		meanX += float64(p.Measured)
+		meanY += float64(p.Reference)
+	}
+	meanX /= n
+	meanY /= n
+	var sxy, sxx float64
+	for _, p := range this.Points {
+		dx := float64(p.Measured) - meanX
+		sxy += dx * (float64(p.Reference) - meanY)
+		sxx += dx * dx
+	}
+	if sxx == 0 {
+		return 0, 0, fmt.Errorf("%w: reference points have the same "+
+			"measured humidity", ErrCalibration)
+	}
+	s := sxy / sxx
+	if s <= 0 {
+		return 0, 0, fmt.Errorf("%w: humidity doesn't grow with reference",
+			ErrCalibration)
+	}
+	return float32(meanY - s*meanX), float32(s), nil
+}
+
+// Return option correcting humidity according to solved calibration,
+// temperature is left as is.
+func (this *Calibration) Option() (Option, error) {
+	offset, scale, err := this.Solve()
+	if err != nil {
+		return nil, err
+	}
+	return WithCalibration(0, 1, offset, scale), nil
+}
+
+// Return true, if standard deviation of raw humidity of readings
+// doesn't exceed maxDeviation percent, so sensor settled
+// at reference humidity.
+func HumidityStable(readings []Reading, maxDeviation float32) bool {
+	if len(readings) < 2 {
+		return false
+	}
+	_, deviation := humidityStats(readings)
+	return deviation <= float64(maxDeviation)
+}
+
+// Return mean and standard deviation of raw humidity.
+func humidityStats(readings []Reading) (mean, deviation float64) {
+	for _, reading := range readings {
+		mean += float64(reading.RawHumidity)
+	}
+	mean /= float64(len(readings))
+	for _, reading := range readings {
+		d := float64(reading.RawHumidity) - mean
+		deviation += d * d
+	}
+	return mean, math.Sqrt(deviation / float64(len(readings)))
+}
@@ -0,0 +1,150 @@
+package dht
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+)
+
+// Readings with raw humidity values given.
+func humidityReadings(values ...float32) []Reading {
+	readings := make([]Reading, len(values))
+	for i, value := range values {
+		readings[i] = Reading{Humidity: Humidity(value),
+			RawHumidity: Humidity(value)}
+	}
+	return readings
+}
+
+func TestCalibrationSolve(t *testing.T) {
+	tests := []struct {
+		name   string
+		points []CalibrationPoint
+		offset float32
+		scale  float32
+	}{
+		// Sensor reads 72.0% over NaCl and 35.0% over MgCl2
+		{"salts", []CalibrationPoint{{Reference: 75.3, Measured: 72},
+			{Reference: 32.8, Measured: 35}}, -7.4027, 1.148649},
+		{"offset only", []CalibrationPoint{{Reference: 75.3, Measured: 77.3},
+			{Reference: 32.8, Measured: 34.8}}, -2, 1},
+		{"three exact", []CalibrationPoint{{Reference: 12.5, Measured: 10},
+			{Reference: 33.5, Measured: 30}, {Reference: 75.5, Measured: 70}},
+			2, 1.05},
+		// Least squares through (10, 12), (20, 20), (30, 34)
+		{"three fitted", []CalibrationPoint{{Reference: 12, Measured: 10},
+			{Reference: 20, Measured: 20}, {Reference: 34, Measured: 30}},
+			0, 1.1},
+	}
+	for _, test := range tests {
+		c := Calibration{Points: test.points}
+		offset, scale, err := c.Solve()
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if math.Abs(float64(offset-test.offset)) > 1e-3 ||
+			math.Abs(float64(scale-test.scale)) > 1e-5 {
+			t.Errorf("%s: got offset %v scale %v, want %v %v", test.name,
+				offset, scale, test.offset, test.scale)
+		}
+	}
+}
+
+func TestCalibrationSolveErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		points []CalibrationPoint
+	}{
+		{"none", nil},
+		{"single", []CalibrationPoint{{Reference: 75.3, Measured: 72}}},
+		{"same measured", []CalibrationPoint{{Reference: 75.3, Measured: 50},
+			{Reference: 32.8, Measured: 50}}},
+		{"inverse", []CalibrationPoint{{Reference: 75.3, Measured: 35},
+			{Reference: 32.8, Measured: 72}}},
+	}
+	for _, test := range tests {
+		c := Calibration{Points: test.points}
+		if _, _, err := c.Solve(); !errors.Is(err, ErrCalibration) {
+			t.Errorf("%s: got %v, want %v", test.name, err, ErrCalibration)
+		}
+		if _, err := c.Option(); !errors.Is(err, ErrCalibration) {
+			t.Errorf("%s: option: got %v, want %v", test.name, err,
+				ErrCalibration)
+		}
+	}
+}
+
+func TestCalibrationWorkflow(t *testing.T) {
+	var c Calibration
+	if err := c.AddReferencePoint(75.3,
+		humidityReadings(71.9, 72.1, 72, 72)); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.AddReferencePoint(32.8,
+		humidityReadings(35.1, 34.9, 35)); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.AddReferencePoint(32.8, nil); !errors.Is(err, ErrCalibration) {
+		t.Errorf("no readings: got %v, want %v", err, ErrCalibration)
+	}
+	if err := c.AddReferencePoint(120,
+		humidityReadings(35)); !errors.Is(err, ErrCalibration) {
+		t.Errorf("bad reference: got %v, want %v", err, ErrCalibration)
+	}
+	if len(c.Points) != 2 || c.Points[0].Samples != 4 ||
+		math.Abs(float64(c.Points[1].Measured-35)) > 1e-4 {
+		t.Fatalf("got points %+v", c.Points)
+	}
+
+	// Calibration is persisted and loaded as JSON
+	data, err := json.Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var loaded Calibration
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		t.Fatal(err)
+	}
+	opt, err := loaded.Option()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Sensor reading 72.0% over NaCl now report reference value
+	cfg, err := newConfig(DHT22, []Option{opt})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct{ raw, want int }{{720, 753}, {350, 328}} {
+		reading, err := decodePulses(DHT22,
+			responsePulses(dht22Bytes(test.raw, 213)), cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reading.HumidityDeci() != uint16(test.want) ||
+			reading.Temperature != 21.3 {
+			t.Errorf("raw %d: got %v %v, want %d‰ 21.3°C", test.raw,
+				reading.Humidity, reading.Temperature, test.want)
+		}
+	}
+}
+
+func TestHumidityStable(t *testing.T) {
+	tests := []struct {
+		values []float32
+		want   bool
+	}{
+		{nil, false},
+		{[]float32{72}, false},
+		{[]float32{72, 72.1, 71.9, 72}, true},
+		{[]float32{70, 72, 74}, false},
+	}
+	for _, test := range tests {
+		if got := HumidityStable(humidityReadings(test.values...),
+			0.2); got != test.want {
+			t.Errorf("%v: stable %v, want %v", test.values, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+
+	"github.com/stanier/go-dht"
+)
+
+// Guide through humidity calibration: for every reference humidity
+// wait until sensor settles, record reference point and finally save
+// solved calibration.
+func calibrate(args []string) error {
+	flags := flag.NewFlagSet("calibrate", flag.ExitOnError)
+	sensorType := dht.DHT22
+	flags.Var(&sensorType, "type", "sensor type: dht11|dht22|am2302")
+	pin := flags.Int("pin", 4, "GPIO pin sensor connected to")
+	points := flags.String("points", "75.3,32.8",
+		"comma separated reference humidities in percent")
+	window := flags.Int("window", 10,
+		"number of last readings which must be stable")
+	deviation := flags.Float64("deviation", 0.3,
+		"maximum standard deviation of stable humidity in percent")
+	out := flags.String("out", "calibration.json", "file to save calibration to")
+	flags.Parse(args)
+
+	var references []float32
+	for _, s := range strings.Split(*points, ",") {
+		rh, err := strconv.ParseFloat(strings.TrimSpace(s), 32)
+		if err != nil {
+			return fmt.Errorf("bad reference humidity %q", s)
+		}
+		references = append(references, float32(rh))
+	}
+	if len(references) < 2 {
+		return fmt.Errorf("at least 2 reference points needed")
+	}
+	if *window < 2 {
+		return fmt.Errorf("window must be at least 2 readings")
+	}
+	sensor, err := dht.NewSensor(sensorType, *pin, dht.WithMinIntervalWait(),
+		dht.WithRetries(3))
+	if err != nil {
+		return err
+	}
+	defer sensor.Close()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var calibration dht.Calibration
+	stdin := bufio.NewReader(os.Stdin)
+	for _, reference := range references {
+		fmt.Printf("Place sensor over %v%% reference and press Enter ",
+			reference)
+		if _, err := stdin.ReadString('\n'); err != nil {
+			return err
+		}
+		readings, err := stableReadings(ctx, sensor, *window,
+			float32(*deviation))
+		if err != nil {
+			return err
+		}
+		if err := calibration.AddReferencePoint(reference, readings); err != nil {
+			return err
+		}
+		point := calibration.Points[len(calibration.Points)-1]
+		fmt.Printf("Reference %v%%: measured %.2f%%\n", point.Reference,
+			point.Measured)
+	}
+	offset, scale, err := calibration.Solve()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Humidity correction: scale %.4f, offset %+.2f%%\n",
+		scale, offset)
+	data, err := json.MarshalIndent(calibration, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(*out, append(data, '\n'), 0644)
+}
+
+// Read sensor until last window readings are stable and return them.
+func stableReadings(ctx context.Context, sensor *dht.Sensor, window int,
+	deviation float32) ([]dht.Reading, error) {
+	var readings []dht.Reading
+	for {
+		reading, err := sensor.ReadContext(ctx)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if err != nil {
+			fmt.Printf("  %v\n", err)
+			continue
+		}
+		readings = append(readings, reading)
+		if len(readings) > window {
+			readings = readings[1:]
+		}
+		fmt.Printf("  %v\n", reading.RawHumidity)
+		if len(readings) == window && dht.HumidityStable(readings, deviation) {
+			return readings, nil
+		}
+	}
+}
@@ -0,0 +1,49 @@
+// Command dht reads DHTxx sensors and helps to debug them in the field:
+//
+//	dht record -pin 4 -type dht22 -count 10 -out traces/
+//	dht replay -negative-encoding auto traces/*.json
+//	dht calibrate -pin 4 -type dht22 -points 75.3,32.8
+//	dht soak -pin 4 -type dht22 -duration 24h -log soak.jsonl
+//
+// Replay doesn't touch GPIO, so it works on any OS.
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// Subcommand taking its arguments, without subcommand name.
+type command struct {
+	run   func(args []string) error
+	usage string
+}
+
+var commands = map[string]command{
+	"calibrate": {calibrate, "fit humidity correction against reference points"},
+	"record":    {record, "capture pulses of sensor and save them as traces"},
+	"replay":    {replay, "decode traces saved by record"},
+	"soak":      {soak, "read sensor for a long time and report failures"},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		usage()
+	}
+	if err := cmd.run(os.Args[2:]); err != nil {
+		fmt.Fprintf(os.Stderr, "dht %s: %v\n", os.Args[1], err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: dht <command> [flags]\n\nCommands:\n")
+	for _, name := range []string{"record", "replay", "soak", "calibrate"} {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", name, commands[name].usage)
+	}
+	os.Exit(2)
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+
+	"github.com/stanier/go-dht"
+)
+
+// Capture pulses count times and save every trace, successful or not.
+func record(args []string) error {
+	flags := flag.NewFlagSet("record", flag.ExitOnError)
+	sensorType := dht.DHT22
+	flags.Var(&sensorType, "type", "sensor type: dht11|dht22|am2302")
+	pin := flags.Int("pin", 4, "GPIO pin sensor connected to")
+	count := flags.Int("count", 10, "number of traces to record")
+	out := flags.String("out", ".", "directory to save traces to")
+	flags.Parse(args)
+
+	if err := os.MkdirAll(*out, 0755); err != nil {
+		return err
+	}
+	sensor, err := dht.NewSensor(sensorType, *pin, dht.WithMinIntervalWait())
+	if err != nil {
+		return err
+	}
+	defer sensor.Close()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	for i := 0; i < *count && ctx.Err() == nil; i++ {
+		trace, reading, err := sensor.Record(ctx)
+		if ctx.Err() != nil {
+			break
+		}
+		path := filepath.Join(*out, fmt.Sprintf("%s-pin%d-%s.json",
+			sensorType, *pin, trace.Time.Format("20060102-150405.000")))
+		if saveErr := trace.Save(path); saveErr != nil {
+			return saveErr
+		}
+		if err != nil {
+			fmt.Printf("%s: %d pulses, %v\n", path, len(trace.Pulses), err)
+		} else {
+			fmt.Printf("%s: %d pulses, %v %v\n", path, len(trace.Pulses),
+				reading.Temperature, reading.Humidity)
+		}
+	}
+	return nil
+}
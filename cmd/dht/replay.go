@@ -0,0 +1,83 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/stanier/go-dht"
+)
+
+var negativeEncodings = map[string]dht.NegativeEncoding{
+	"sign": dht.SignMagnitude,
+	"twos": dht.TwosComplement,
+	"auto": dht.AutoDetect,
+}
+
+// Decode traces and print result, bits with their timing and
+// histogram of high pulses.
+func replay(args []string) error {
+	flags := flag.NewFlagSet("replay", flag.ExitOnError)
+	var sensorType dht.SensorType
+	flags.Var(&sensorType, "type", "decode as sensor type: dht11|dht22|am2302, "+
+		"recorded one by default")
+	encoding := flags.String("negative-encoding", "sign",
+		"negative DHT22 temperature encoding: sign|twos|auto")
+	strictFrame := flags.Bool("strict-frame", false,
+		"find frame by pulse count only")
+	flags.Parse(args)
+
+	opts := []dht.Option{}
+	if enc, ok := negativeEncodings[*encoding]; ok {
+		opts = append(opts, dht.WithNegativeEncoding(enc))
+	} else {
+		return fmt.Errorf("unknown negative encoding %q", *encoding)
+	}
+	if *strictFrame {
+		opts = append(opts, dht.WithStrictFrame())
+	}
+	if flags.NArg() == 0 {
+		return fmt.Errorf("no trace files given")
+	}
+	for _, path := range flags.Args() {
+		trace, err := dht.LoadTrace(path)
+		if err != nil {
+			return err
+		}
+		if sensorType != 0 {
+			trace.Sensor = sensorType
+		}
+		printReplay(path, trace, opts)
+	}
+	return nil
+}
+
+func printReplay(path string, trace dht.Trace, opts []dht.Option) {
+	fmt.Printf("%s: %v on pin %d at %v, %d pulses\n", path, trace.Sensor,
+		trace.Pin, trace.Time.Format("2006-01-02 15:04:05"), len(trace.Pulses))
+	if trace.Error != "" {
+		fmt.Printf("  recorded error: %s\n", trace.Error)
+	}
+	reading, err := trace.Decode(opts...)
+	if err != nil {
+		fmt.Printf("  decoded: %v (%v)\n", err, dht.Kind(err))
+	} else {
+		fmt.Printf("  decoded: %v %v\n", reading.Temperature, reading.Humidity)
+	}
+	fmt.Printf("  pulses: %v\n", trace.Pulses)
+	if bits, err := trace.Pulses.Bits(); err == nil {
+		var buf strings.Builder
+		for i, bit := range bits {
+			if i > 0 && i%8 == 0 {
+				buf.WriteByte(' ')
+			}
+			fmt.Fprint(&buf, bit)
+		}
+		fmt.Printf("  bits: %s\n", buf.String())
+	}
+	fmt.Printf("  high pulses:\n")
+	for _, bucket := range trace.Pulses.Histogram() {
+		fmt.Printf("    %4d-%-4dus %s\n", bucket.From.Microseconds(),
+			bucket.To.Microseconds(), strings.Repeat("#", bucket.Count))
+	}
+}
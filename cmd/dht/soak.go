@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/stanier/go-dht"
+)
+
+// Read sensor for a long time and report how reliable reads are.
+// Interrupted run still prints and saves report of reads made so far.
+func soak(args []string) error {
+	flags := flag.NewFlagSet("soak", flag.ExitOnError)
+	sensorType := dht.DHT22
+	flags.Var(&sensorType, "type", "sensor type: dht11|dht22|am2302")
+	pin := flags.Int("pin", 4, "GPIO pin sensor connected to")
+	duration := flags.Duration("duration", 24*time.Hour, "duration of run")
+	logPath := flags.String("log", "", "file to append every read outcome to, as JSON lines")
+	reportPath := flags.String("report", "", "file to save report to, as JSON")
+	flags.Parse(args)
+
+	var w io.Writer
+	if *logPath != "" {
+		f, err := os.OpenFile(*logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND,
+			0644)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	sensor, err := dht.NewSensor(sensorType, *pin, dht.WithMinIntervalWait())
+	if err != nil {
+		return err
+	}
+	defer sensor.Close()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	report, err := dht.Soak(ctx, sensor, *duration, w)
+	if err != nil && !errors.Is(err, context.Canceled) {
+		return err
+	}
+	fmt.Print(report)
+	if *reportPath != "" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(*reportPath, append(data, '\n'), 0644)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+// Package compat provides functions with the same names and signatures
+// as github.com/d2r2/go-dht, so code written against that package can
+// switch imports without other changes.
+//
+// Behavioral differences from d2r2/go-dht:
+// 1) DHT12 sensor type is not supported;
+// 2) cancelled context make ReadDHTxxWithContextAndRetry return error
+// wrapping ctx.Err() rather than ctx.Err() itself;
+// 3) d2r2 logger settings have no counterpart here, all diagnostic output
+// goes through this package logger;
+// 4) on error temperature and humidity are 0 rather than -1;
+// 5) only transient errors, such as checksum mismatch or missing
+// response, are retried: GPIO, permission errors and missing sensor
+// are returned at once;
+// 6) pause between retries is 1 second for DHT11 and 2 seconds for DHT22
+// rather than 1.5 seconds;
+// 7) back-to-back calls on the same pin block until sensor minimum
+// interval (see dht.SensorType.MinInterval) elapsed since previous
+// successful read.
+package compat
+
+import (
+	"context"
+
+	"github.com/stanier/go-dht"
+)
+
+type SensorType = dht.SensorType
+
+const (
+	DHT11  = dht.DHT11
+	DHT22  = dht.DHT22
+	AM2302 = dht.AM2302
+)
+
+// Read temperature and humidity from sensor, see dht.ReadDHTxx.
+func ReadDHTxx(sensorType SensorType, pin int,
+	boostPerfFlag bool) (temperature float32, humidity float32, err error) {
+	return dht.ReadDHTxx(sensorType, pin, boostPerfFlag)
+}
+
+// Read temperature and humidity from sensor retrying in case of failure,
+// see dht.ReadDHTxxWithRetry.
+func ReadDHTxxWithRetry(sensorType SensorType, pin int, boostPerfFlag bool,
+	retry int) (temperature float32, humidity float32, retried int, err error) {
+	return dht.ReadDHTxxWithRetry(sensorType, pin, boostPerfFlag, retry)
+}
+
+// Read temperature and humidity from sensor retrying in case of failure
+// until parent context is done, see dht.ReadDHTxxWithRetryContext.
+func ReadDHTxxWithContextAndRetry(parent context.Context, sensorType SensorType,
+	pin int, boostPerfFlag bool, retry int) (temperature float32,
+	humidity float32, retried int, err error) {
+	return dht.ReadDHTxxWithRetryContext(parent, sensorType, pin,
+		boostPerfFlag, retry)
+}
@@ -0,0 +1,258 @@
+package compat
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kidoman/embd"
+	"github.com/stanier/go-dht"
+)
+
+// Host registered in embd, whose GPIO driver open fake pins,
+// so functions taking pin number read fake sensors.
+const fakeHost embd.Host = "dht-compat-test"
+
+// Pins available on fake host.
+const (
+	firstPin = 4
+	lastPin  = 1000
+)
+
+// Bytes sent by sensor attached to pin, nil means no sensor response.
+var responses = struct {
+	sync.Mutex
+	m    map[int][]level
+	next int
+}{m: make(map[int][]level), next: firstPin}
+
+func TestMain(m *testing.M) {
+	var pins embd.PinMap
+	for n := firstPin; n <= lastPin; n++ {
+		pins = append(pins, &embd.PinDesc{ID: "GPIO_" + strconv.Itoa(n),
+			Aliases: []string{strconv.Itoa(n)}, Caps: embd.CapDigital,
+			DigitalLogical: n})
+	}
+	embd.Register(fakeHost, func(rev int) *embd.Descriptor {
+		return &embd.Descriptor{GPIODriver: func() embd.GPIODriver {
+			return embd.NewGPIODriver(pins, newFakePin, nil, nil)
+		}}
+	})
+	embd.SetHost(fakeHost, 0)
+	os.Exit(m.Run())
+}
+
+// Attach sensor sending bytes b (no sensor, if b is nil) to unused pin,
+// so reads don't wait for minimum interval, and return pin number.
+func attach(t *testing.T, b []byte) int {
+	responses.Lock()
+	defer responses.Unlock()
+	if responses.next > lastPin {
+		t.Fatal("no more fake pins")
+	}
+	pin := responses.next
+	responses.next++
+	if b != nil {
+		responses.m[pin] = responseWave(b)
+	}
+	return pin
+}
+
+// Line level lasting for duration.
+type level struct {
+	value int
+	dur   time.Duration
+}
+
+// Build wave sensor sends for 5 bytes: preamble, then every bit
+// as 50us low followed by 24us (0) or 70us (1) high.
+func responseWave(b []byte) []level {
+	wave := []level{{1, 30 * time.Microsecond},
+		{0, 80 * time.Microsecond}, {1, 80 * time.Microsecond}}
+	for _, x := range b {
+		for i := 7; i >= 0; i-- {
+			high := 24 * time.Microsecond
+			if x>>i&1 != 0 {
+				high = 70 * time.Microsecond
+			}
+			wave = append(wave, level{0, 50 * time.Microsecond},
+				level{1, high})
+		}
+	}
+	return append(wave, level{0, 50 * time.Microsecond})
+}
+
+// Build bytes sent by DHT22 for humidity and temperature in tenths.
+func dht22Bytes(hum, temp int16) []byte {
+	word := uint16(temp)
+	if temp < 0 {
+		word = uint16(-temp) | 0x8000
+	}
+	b := []byte{byte(hum >> 8), byte(hum), byte(word >> 8), byte(word), 0}
+	b[4] = b[0] + b[1] + b[2] + b[3]
+	return b
+}
+
+// Fake pin playing back sensor response in real time, once line is
+// released after start signal. Line is pulled up otherwise.
+type fakePin struct {
+	mu       sync.Mutex
+	n        int
+	id       string
+	drv      embd.GPIODriver
+	wave     []level
+	dir      embd.Direction
+	out      int
+	released time.Time
+}
+
+func newFakePin(pd *embd.PinDesc, drv embd.GPIODriver) embd.DigitalPin {
+	responses.Lock()
+	defer responses.Unlock()
+	return &fakePin{n: pd.DigitalLogical, id: pd.ID, drv: drv,
+		wave: responses.m[pd.DigitalLogical]}
+}
+
+func (this *fakePin) Watch(edge embd.Edge, handler func(embd.DigitalPin)) error {
+	return errors.New("not supported")
+}
+
+func (this *fakePin) StopWatching() error {
+	return errors.New("not supported")
+}
+
+func (this *fakePin) N() int {
+	return this.n
+}
+
+func (this *fakePin) Write(val int) error {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	this.out = val
+	return nil
+}
+
+func (this *fakePin) Read() (int, error) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	if this.dir == embd.Out {
+		return this.out, nil
+	}
+	if this.released.IsZero() {
+		return 1, nil
+	}
+	elapsed := time.Since(this.released)
+	for _, l := range this.wave {
+		if elapsed < l.dur {
+			return l.value, nil
+		}
+		elapsed -= l.dur
+	}
+	return 1, nil
+}
+
+func (this *fakePin) TimePulse(state int) (time.Duration, error) {
+	return 0, errors.New("not supported")
+}
+
+func (this *fakePin) SetDirection(dir embd.Direction) error {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	if this.dir == embd.Out && dir == embd.In {
+		this.released = time.Now()
+	}
+	this.dir = dir
+	return nil
+}
+
+func (this *fakePin) ActiveLow(b bool) error {
+	return nil
+}
+
+func (this *fakePin) PullUp() error {
+	return nil
+}
+
+func (this *fakePin) PullDown() error {
+	return nil
+}
+
+func (this *fakePin) Close() error {
+	this.drv.Unregister(this.id)
+	return nil
+}
+
+// Code written against d2r2/go-dht get the same values through compat
+// as native API return.
+func TestSameAsNative(t *testing.T) {
+	// Fake sensor is played back in real time, so descheduled test may
+	// see broken frame. Read fresh pin again then, rather than retry
+	// on the same pin after delay.
+	const retry, attempts = 0, 5
+	calls := []struct {
+		name string
+		read func(pin int) (float32, float32, error)
+	}{
+		{"ReadDHTxx", func(pin int) (float32, float32, error) {
+			return ReadDHTxx(DHT22, pin, false)
+		}},
+		{"ReadDHTxxWithRetry", func(pin int) (float32, float32, error) {
+			temperature, humidity, _, err := ReadDHTxxWithRetry(AM2302, pin,
+				false, retry)
+			return temperature, humidity, err
+		}},
+		{"ReadDHTxxWithContextAndRetry", func(pin int) (float32, float32, error) {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+			temperature, humidity, _, err := ReadDHTxxWithContextAndRetry(ctx,
+				DHT22, pin, false, retry)
+			return temperature, humidity, err
+		}},
+	}
+	for _, values := range [][2]int16{{452, 213}, {652, -35}, {1000, -400}} {
+		b := dht22Bytes(values[0], values[1])
+		want, err := dht.Read(dht.DHT22, attach(t, b), dht.WithRetries(retry))
+		for i := 1; i < attempts && dht.IsTransient(err); i++ {
+			want, err = dht.Read(dht.DHT22, attach(t, b), dht.WithRetries(retry))
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want.Temperature.Celsius() != float32(values[1])/10 ||
+			want.Humidity.Percent() != float32(values[0])/10 {
+			t.Fatalf("native API got %v, want %v", want, values)
+		}
+		for _, call := range calls {
+			temperature, humidity, err := call.read(attach(t, b))
+			for i := 1; i < attempts && dht.IsTransient(err); i++ {
+				temperature, humidity, err = call.read(attach(t, b))
+			}
+			if err != nil {
+				t.Errorf("%s: %v", call.name, err)
+				continue
+			}
+			if temperature != want.Temperature.Celsius() ||
+				humidity != want.Humidity.Percent() {
+				t.Errorf("%s: got %v°C %v%%, want %v", call.name, temperature,
+					humidity, want)
+			}
+		}
+	}
+}
+
+// Failed read return zero values and the same error as native API.
+func TestSameErrorAsNative(t *testing.T) {
+	_, want := dht.Read(dht.DHT22, attach(t, nil))
+	temperature, humidity, err := ReadDHTxx(DHT22, attach(t, nil), false)
+	if err == nil || err.Error() != want.Error() ||
+		dht.Kind(err) != dht.Kind(want) {
+		t.Errorf("got %v, want %v", err, want)
+	}
+	if temperature != 0 || humidity != 0 {
+		t.Errorf("got %v°C %v%% on error, want zero", temperature, humidity)
+	}
+}
@@ -0,0 +1,190 @@
+package dht
+
+import (
+	"math"
+	"time"
+)
+
+// Readings buffered per source while waiting for their pair.
+const condensationBuffer = 8
+
+// Return how far surface temperature is above dew point of air with
+// temperature and relative humidity, all in Celsius. Water condense
+// on surface once spread drops to zero. NaN when humidity isn't positive.
+func DewPointSpread(surface, temperature, humidity float32) float32 {
+	return surface - DewPoint(temperature, humidity)
+}
+
+// Condensation risk evaluated for a reading of primary sensor.
+type CondensationState struct {
+	// Time of primary reading.
+	Time time.Time
+	// Dew point of air measured by primary sensor in Celsius.
+	DewPoint float32
+	// Surface temperature in Celsius, static or read by reference sensor.
+	Reference float32
+	// Reference temperature minus dew point.
+	Spread float32
+	// True while alert is active.
+	Active bool
+	// True, if alert was activated or cleared by this state.
+	Changed bool
+}
+
+// CondensationAlert watch dew point of air measured by primary sensor
+// against temperature of surface water could condense on, such as
+// window or wall at roughly outside temperature. Alert is activated
+// once spread drops below Threshold and cleared once it rises
+// to Threshold+Hysteresis again.
+//
+// Surface temperature is either static or read by reference sensor.
+// Readings of two sensors are paired by nearest time within MaxSkew,
+// so sensors can be read with different intervals.
+type CondensationAlert struct {
+	Threshold  float32
+	Hysteresis float32
+	// Names of sources readings are added from. Reference reading
+	// isn't used, if Reference is empty.
+	Primary, Reference string
+	// Static surface temperature in Celsius, used if Reference is empty.
+	ReferenceTemperature float32
+	// Maximum time between paired readings.
+	MaxSkew time.Duration
+
+	active bool
+	// Primary readings waiting for reference readings to come.
+	pending []Reading
+	// Reference readings which could still be nearest to primary one.
+	references []Reading
+	// Time of last primary reading paired.
+	paired time.Time
+}
+
+// Create alert comparing dew point of primary sensor with static
+// surface temperature in Celsius.
+func NewCondensationAlert(primary string, reference float32,
+	threshold, hysteresis float32) *CondensationAlert {
+	return &CondensationAlert{Threshold: threshold, Hysteresis: hysteresis,
+		Primary: primary, ReferenceTemperature: reference}
+}
+
+// Create alert comparing dew point of primary sensor with temperature
+// read by reference sensor within maxSkew of primary reading.
+func NewPairedCondensationAlert(primary, reference string,
+	threshold, hysteresis float32, maxSkew time.Duration) *CondensationAlert {
+	return &CondensationAlert{Threshold: threshold, Hysteresis: hysteresis,
+		Primary: primary, Reference: reference, MaxSkew: maxSkew}
+}
+
+// Return true while alert is active.
+func (this *CondensationAlert) Active() bool {
+	return this.active
+}
+
+// Add reading of named source and return states of primary readings
+// paired so far, oldest first. Primary reading is paired once reference
+// reading not older than it comes, since later ones can't be nearer,
+// or once too many primary readings wait.
+// Readings of each source are expected in time order, though sources
+// could be added in any order. Readings of other sources, readings
+// with invalid fields (see Reading.TemperatureOK) and primary readings
+// without reference reading within MaxSkew are ignored.
+func (this *CondensationAlert) Add(source string,
+	reading Reading) []CondensationState {
+	switch {
+	case source == this.Primary:
+		if !reading.TemperatureOK() || !reading.HumidityOK() {
+			return nil
+		}
+		if this.Reference == "" {
+			state, ok := this.evaluate(reading, this.ReferenceTemperature)
+			if !ok {
+				return nil
+			}
+			return []CondensationState{state}
+		}
+		this.pending = append(this.pending, reading)
+	case source == this.Reference:
+		if !reading.TemperatureOK() {
+			return nil
+		}
+		this.references = append(this.references, reading)
+		if len(this.references) > condensationBuffer {
+			this.references = this.references[1:]
+		}
+	default:
+		return nil
+	}
+	return this.pair()
+}
+
+// Pair pending primary readings, which can't get nearer reference.
+func (this *CondensationAlert) pair() []CondensationState {
+	var states []CondensationState
+	for len(this.pending) > 0 {
+		primary := this.pending[0]
+		reference, ok, final := this.nearestReference(primary.Time)
+		if !final && len(this.pending) <= condensationBuffer {
+			break
+		}
+		this.pending = this.pending[1:]
+		this.paired = primary.Time
+		if !ok {
+			continue
+		}
+		state, ok := this.evaluate(primary, float32(reference.Temperature))
+		if ok {
+			states = append(states, state)
+		}
+	}
+	// Reference older than another one, which is still older than any
+	// primary reading to come, won't be nearest anymore
+	for len(this.references) > 1 && !this.references[1].Time.After(this.paired) {
+		this.references = this.references[1:]
+	}
+	return states
+}
+
+// Find reference reading nearest to t within MaxSkew. Result is final,
+// if there is reference reading not older than t.
+func (this *CondensationAlert) nearestReference(t time.Time) (reading Reading,
+	ok, final bool) {
+	best := this.MaxSkew
+	for _, reference := range this.references {
+		skew := reference.Time.Sub(t)
+		if skew >= 0 {
+			final = true
+		} else {
+			skew = -skew
+		}
+		if skew <= best {
+			reading, ok, best = reference, true, skew
+		}
+		if final {
+			break
+		}
+	}
+	return reading, ok, final
+}
+
+// Update alert with spread of primary reading to reference temperature.
+func (this *CondensationAlert) evaluate(primary Reading,
+	reference float32) (CondensationState, bool) {
+	dewPoint := DewPoint(float32(primary.Temperature),
+		float32(primary.Humidity))
+	if math.IsNaN(float64(dewPoint)) {
+		return CondensationState{}, false
+	}
+	spread := reference - dewPoint
+	active := this.active
+	if spread < this.Threshold {
+		active = true
+	} else if spread >= this.Threshold+this.Hysteresis {
+		active = false
+	}
+	state := CondensationState{Time: primary.Time, DewPoint: dewPoint,
+		Reference: reference, Spread: spread, Active: active,
+		Changed: active != this.active}
+	this.active = active
+	return state, true
+}
@@ -0,0 +1,146 @@
+package dht
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestDewPointSpread(t *testing.T) {
+	if got, want := DewPointSpread(10, 20, 50), 10-DewPoint(20, 50); got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got := DewPointSpread(10, 20, 0); !math.IsNaN(float64(got)) {
+		t.Errorf("got %v for dry air, want NaN", got)
+	}
+}
+
+func TestCondensationAlertStatic(t *testing.T) {
+	alert := NewCondensationAlert("inside", 10, 2, 1)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		source   string
+		humidity Humidity
+		bad      bool
+		// Expected spread, negative if reading is ignored.
+		spread  float32
+		active  bool
+		changed bool
+	}{
+		{"inside", 45, false, 2.30, false, false},
+		{"inside", 50, false, 0.74, true, true},
+		{"inside", 47, false, 1.66, true, false},
+		// Within hysteresis
+		{"inside", 44, false, 2.63, true, false},
+		{"inside", 90, true, -1, true, false},
+		{"outside", 90, false, -1, true, false},
+		{"inside", 0, false, -1, true, false},
+		{"inside", 40, false, 4.02, false, true},
+	}
+	for i, test := range tests {
+		reading := Reading{Time: start.Add(time.Duration(i) * time.Minute),
+			Temperature: 20, Humidity: test.humidity, humidityBad: test.bad}
+		states := alert.Add(test.source, reading)
+		if test.spread < 0 {
+			if len(states) != 0 {
+				t.Errorf("%d: got %+v, want reading ignored", i, states)
+			}
+			continue
+		}
+		if len(states) != 1 {
+			t.Fatalf("%d: got %d states, want 1", i, len(states))
+		}
+		state := states[0]
+		if !state.Time.Equal(reading.Time) || state.Reference != 10 ||
+			math.Abs(float64(state.Spread-test.spread)) > 0.01 ||
+			state.Active != test.active || state.Changed != test.changed {
+			t.Errorf("%d: got %+v, want spread %v active %v changed %v", i,
+				state, test.spread, test.active, test.changed)
+		}
+		if alert.Active() != test.active {
+			t.Errorf("%d: alert active %v", i, alert.Active())
+		}
+	}
+}
+
+func TestCondensationAlertPaired(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(seconds int, temp Temperature) Reading {
+		return Reading{Time: start.Add(time.Duration(seconds) * time.Second),
+			Temperature: temp, Humidity: 50}
+	}
+	type input struct {
+		source  string
+		reading Reading
+	}
+	// Primary read every 2 seconds, reference every 5 seconds
+	var primary, reference []input
+	for s := 0; s <= 10; s += 2 {
+		primary = append(primary, input{"inside", at(s, 20)})
+	}
+	for i, s := range []int{1, 6, 11} {
+		reference = append(reference,
+			input{"window", at(s, Temperature(10+i))})
+	}
+	// Nearest reference within 3 seconds of each primary reading
+	want := []float32{10, 10, 11, 11, 11, 12}
+	orders := map[string][]input{
+		"primary first":   append(append([]input{}, primary...), reference...),
+		"reference first": append(append([]input{}, reference...), primary...),
+		"interleaved": {primary[0], primary[1], reference[0], primary[2],
+			primary[3], primary[4], reference[1], reference[2], primary[5]},
+	}
+	for name, inputs := range orders {
+		alert := NewPairedCondensationAlert("inside", "window", 2, 1,
+			3*time.Second)
+		var states []CondensationState
+		for _, in := range inputs {
+			states = append(states, alert.Add(in.source, in.reading)...)
+		}
+		if len(states) != len(want) {
+			t.Errorf("%s: got %d states, want %d", name, len(states), len(want))
+			continue
+		}
+		for i, state := range states {
+			if !state.Time.Equal(primary[i].reading.Time) ||
+				state.Reference != want[i] ||
+				state.Spread != DewPointSpread(want[i], 20, 50) {
+				t.Errorf("%s: state %d: got %+v, want reference %v", name, i,
+					state, want[i])
+			}
+		}
+		if !states[0].Active || !states[0].Changed || states[1].Changed {
+			t.Errorf("%s: activation %+v", name, states[:2])
+		}
+	}
+}
+
+func TestCondensationAlertSkew(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(seconds int) Reading {
+		return Reading{Time: start.Add(time.Duration(seconds) * time.Second),
+			Temperature: 20, Humidity: 50}
+	}
+	alert := NewPairedCondensationAlert("inside", "window", 2, 1,
+		3*time.Second)
+	// Reference too late to pair with
+	alert.Add("inside", at(0))
+	if states := alert.Add("window", at(10)); len(states) != 0 {
+		t.Errorf("got %+v, want no pair beyond maximum skew", states)
+	}
+	// Reference stopped, so only a few primary readings are kept
+	for s := 20; s < 60; s++ {
+		if states := alert.Add("inside", at(s)); len(states) != 0 {
+			t.Fatalf("got %+v, want no pair without reference", states)
+		}
+	}
+	if n := len(alert.pending); n > condensationBuffer {
+		t.Errorf("%d readings pending, want at most %d", n, condensationBuffer)
+	}
+	// Reference is back, waiting readings within maximum skew are paired
+	states := alert.Add("window", at(60))
+	if len(states) != 3 || !states[0].Time.Equal(at(57).Time) ||
+		!states[2].Time.Equal(at(59).Time) {
+		t.Errorf("got %+v, want readings at 57-59s paired", states)
+	}
+}
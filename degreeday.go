@@ -0,0 +1,238 @@
+package dht
+
+import (
+	"time"
+)
+
+// Kind of degree-day to accumulate.
+type DegreeDayKind int
+
+const (
+	// Sum of degrees below base temperature (heating demand).
+	HeatingDegreeDays DegreeDayKind = iota + 1
+	// Sum of degrees above base temperature (plant growth, cooling demand).
+	GrowingDegreeDays
+)
+
+// Method used to calculate degree-days value for a single day.
+type DegreeDayMethod int
+
+const (
+	// Use mean of daily minimum and maximum temperature.
+	DailyMeanMethod DegreeDayMethod = iota + 1
+	// Integrate sampled temperature curve over the day.
+	IntegrationMethod
+)
+
+// Policy applied when interval between two samples exceed MaxGap.
+// Days completely covered by an excluded gap get no entry at all.
+type DegreeDayGapPolicy int
+
+const (
+	// Bridge the gap with straight line between surrounding samples.
+	InterpolateGaps DegreeDayGapPolicy = iota + 1
+	// Do not account time covered by the gap at all.
+	ExcludeGaps
+)
+
+// Degree-days value accumulated for one calendar day.
+type DegreeDay struct {
+	// Local midnight the day starts with.
+	Date time.Time
+	// Degree-days value.
+	Value float32
+	// Number of real samples which fall into the day.
+	Samples int
+	// True, if some part of the day was excluded due to a gap.
+	Gap bool
+}
+
+// DegreeDayAccumulator consume temperature samples and sum them up
+// to degree-days per calendar day and in total.
+// Day boundaries are calculated in Location time zone,
+// so DST transitions produce 23 or 25 hours long days.
+type DegreeDayAccumulator struct {
+	Kind   DegreeDayKind
+	Base   float32
+	Method DegreeDayMethod
+	// Time zone used to detect day boundaries (time.Local, if nil).
+	Location *time.Location
+	// Samples separated by more than MaxGap are treated as a gap
+	// (zero means never).
+	MaxGap    time.Duration
+	GapPolicy DegreeDayGapPolicy
+
+	days     []DegreeDay
+	current  DegreeDay
+	seen     bool
+	min, max float32
+	// Integral of degrees over time in degree*seconds.
+	integral float64
+	started  bool
+	lastT    time.Time
+	lastV    float32
+}
+
+// Create degree-days accumulator with base temperature in Celsius.
+// By default gaps longer than 1 hour are interpolated.
+func NewDegreeDayAccumulator(kind DegreeDayKind, base float32,
+	method DegreeDayMethod, loc *time.Location) *DegreeDayAccumulator {
+	return &DegreeDayAccumulator{Kind: kind, Base: base, Method: method,
+		Location: loc, MaxGap: time.Hour, GapPolicy: InterpolateGaps}
+}
+
+// Add temperature sample in Celsius taken at time t.
+// Samples not newer than the previous one are ignored.
+func (this *DegreeDayAccumulator) Add(t time.Time, temperature float32) {
+	if !this.started {
+		this.started = true
+		this.startDay(this.dayOf(t))
+		this.observe(temperature)
+		this.current.Samples++
+		this.lastT, this.lastV = t, temperature
+		return
+	}
+	if !t.After(this.lastT) {
+		return
+	}
+	if this.MaxGap > 0 && t.Sub(this.lastT) > this.MaxGap &&
+		this.GapPolicy == ExcludeGaps {
+		day := this.dayOf(t)
+		this.current.Gap = true
+		if !day.Equal(this.current.Date) {
+			this.finishDay()
+			this.startDay(day)
+			this.current.Gap = true
+		}
+	} else {
+		a, va := this.lastT, this.lastV
+		for {
+			boundary := this.nextDay(a)
+			if t.Before(boundary) {
+				this.segment(a, va, t, temperature)
+				break
+			}
+			vb := va + (temperature-va)*
+				float32(boundary.Sub(a).Seconds()/t.Sub(a).Seconds())
+			this.segment(a, va, boundary, vb)
+			this.observe(vb)
+			this.finishDay()
+			this.startDay(boundary)
+			this.observe(vb)
+			a, va = boundary, vb
+			// Sample taken exactly at midnight opens the new day.
+			if !t.After(a) {
+				break
+			}
+		}
+	}
+	this.observe(temperature)
+	this.current.Samples++
+	this.lastT, this.lastV = t, temperature
+}
+
+// Return completed days in chronological order.
+func (this *DegreeDayAccumulator) Days() []DegreeDay {
+	days := make([]DegreeDay, len(this.days))
+	copy(days, this.days)
+	return days
+}
+
+// Return day in progress with value accumulated so far.
+func (this *DegreeDayAccumulator) Today() DegreeDay {
+	day := this.current
+	day.Value = this.value()
+	return day
+}
+
+// Return running total including day in progress.
+func (this *DegreeDayAccumulator) Total() float32 {
+	var total float32
+	for _, day := range this.days {
+		total += day.Value
+	}
+	if this.started {
+		total += this.value()
+	}
+	return total
+}
+
+func (this *DegreeDayAccumulator) location() *time.Location {
+	if this.Location == nil {
+		return time.Local
+	}
+	return this.Location
+}
+
+func (this *DegreeDayAccumulator) dayOf(t time.Time) time.Time {
+	y, m, d := t.In(this.location()).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, this.location())
+}
+
+func (this *DegreeDayAccumulator) nextDay(t time.Time) time.Time {
+	y, m, d := t.In(this.location()).Date()
+	return time.Date(y, m, d+1, 0, 0, 0, 0, this.location())
+}
+
+// Degrees counted towards degree-days for temperature v.
+func (this *DegreeDayAccumulator) excess(v float32) float32 {
+	if this.Kind == HeatingDegreeDays {
+		return this.Base - v
+	}
+	return v - this.Base
+}
+
+func (this *DegreeDayAccumulator) startDay(day time.Time) {
+	this.current = DegreeDay{Date: day}
+	this.integral = 0
+	this.seen = false
+}
+
+func (this *DegreeDayAccumulator) finishDay() {
+	this.current.Value = this.value()
+	this.days = append(this.days, this.current)
+}
+
+func (this *DegreeDayAccumulator) observe(v float32) {
+	if !this.seen {
+		this.seen = true
+		this.min, this.max = v, v
+	}
+	if v < this.min {
+		this.min = v
+	}
+	if v > this.max {
+		this.max = v
+	}
+}
+
+// Accumulate positive part of excess between two points,
+// assuming temperature change linearly.
+func (this *DegreeDayAccumulator) segment(a time.Time, va float32,
+	b time.Time, vb float32) {
+	dur := b.Sub(a).Seconds()
+	ea, eb := float64(this.excess(va)), float64(this.excess(vb))
+	switch {
+	case ea >= 0 && eb >= 0:
+		this.integral += (ea + eb) / 2 * dur
+	case ea > 0 && eb < 0:
+		this.integral += ea / 2 * dur * ea / (ea - eb)
+	case ea < 0 && eb > 0:
+		this.integral += eb / 2 * dur * eb / (eb - ea)
+	}
+}
+
+// Degree-days value of day in progress.
+func (this *DegreeDayAccumulator) value() float32 {
+	if this.Method == IntegrationMethod {
+		return float32(this.integral / (24 * time.Hour).Seconds())
+	}
+	if !this.seen {
+		return 0
+	}
+	e := this.excess((this.min + this.max) / 2)
+	if e < 0 {
+		return 0
+	}
+	return e
+}
@@ -0,0 +1,166 @@
+package dht
+
+import (
+	"math"
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func loadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc
+}
+
+func near(a, b float32) bool {
+	return math.Abs(float64(a-b)) < 1e-3
+}
+
+// Feed constant temperature every step from start until end inclusive.
+func feedConstant(acc *DegreeDayAccumulator, start, end time.Time,
+	step time.Duration, temperature float32) {
+	for t := start; !t.After(end); t = t.Add(step) {
+		acc.Add(t, temperature)
+	}
+}
+
+func TestDegreeDayMidnightSamples(t *testing.T) {
+	loc := loadLocation(t, "America/New_York")
+	acc := NewDegreeDayAccumulator(HeatingDegreeDays, 18, DailyMeanMethod, loc)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, loc)
+	// 72 hours of half-hourly samples: 00:00 .. 71:30.
+	feedConstant(acc, start, start.Add(71*time.Hour+30*time.Minute),
+		30*time.Minute, 10)
+
+	days := acc.Days()
+	if len(days) != 2 {
+		t.Fatalf("got %d completed days, want 2: %+v", len(days), days)
+	}
+	for i, day := range days {
+		want := time.Date(2024, 1, 1+i, 0, 0, 0, 0, loc)
+		if !day.Date.Equal(want) {
+			t.Errorf("day %d: date %v, want %v", i, day.Date, want)
+		}
+		if !near(day.Value, 8) {
+			t.Errorf("day %d: value %v, want 8", i, day.Value)
+		}
+		if day.Samples != 48 {
+			t.Errorf("day %d: %d samples, want 48", i, day.Samples)
+		}
+	}
+	today := acc.Today()
+	if !today.Date.Equal(time.Date(2024, 1, 3, 0, 0, 0, 0, loc)) {
+		t.Errorf("today: date %v, want 2024-01-03", today.Date)
+	}
+	if today.Samples != 48 {
+		t.Errorf("today: %d samples, want 48", today.Samples)
+	}
+	if total := acc.Total(); !near(total, 24) {
+		t.Errorf("total %v, want 24", total)
+	}
+}
+
+func TestDegreeDayDST(t *testing.T) {
+	loc := loadLocation(t, "America/New_York")
+	tests := []struct {
+		name  string
+		start time.Time
+		// Length of the middle day in hours.
+		hours float32
+	}{
+		{"spring forward", time.Date(2024, 3, 9, 0, 0, 0, 0, loc), 23},
+		{"fall back", time.Date(2024, 11, 2, 0, 0, 0, 0, loc), 25},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			acc := NewDegreeDayAccumulator(HeatingDegreeDays, 18,
+				IntegrationMethod, loc)
+			end := time.Date(test.start.Year(), test.start.Month(),
+				test.start.Day()+3, 0, 0, 0, 0, loc)
+			feedConstant(acc, test.start, end, 30*time.Minute, 10)
+
+			days := acc.Days()
+			if len(days) != 3 {
+				t.Fatalf("got %d completed days, want 3: %+v", len(days), days)
+			}
+			want := []float32{8, 8 * test.hours / 24, 8}
+			for i, day := range days {
+				if !near(day.Value, want[i]) {
+					t.Errorf("day %d: value %v, want %v", i, day.Value, want[i])
+				}
+			}
+			if days[1].Samples != int(test.hours*2) {
+				t.Errorf("middle day: %d samples, want %d",
+					days[1].Samples, int(test.hours*2))
+			}
+			today := acc.Today()
+			if !today.Date.Equal(end) || today.Samples != 1 || today.Value != 0 {
+				t.Errorf("today: %+v, want empty day at %v", today, end)
+			}
+		})
+	}
+}
+
+func TestDegreeDayGaps(t *testing.T) {
+	loc := loadLocation(t, "America/New_York")
+	before := time.Date(2024, 1, 1, 22, 0, 0, 0, loc)
+
+	t.Run("interpolate", func(t *testing.T) {
+		acc := NewDegreeDayAccumulator(HeatingDegreeDays, 18,
+			DailyMeanMethod, loc)
+		acc.Add(before, 0)
+		acc.Add(before.Add(4*time.Hour), 4)
+		days := acc.Days()
+		if len(days) != 1 {
+			t.Fatalf("got %d completed days, want 1", len(days))
+		}
+		// Midnight is interpolated to 2 degrees.
+		if days[0].Gap || !near(days[0].Value, 17) {
+			t.Errorf("first day: %+v, want value 17 without gap", days[0])
+		}
+		if today := acc.Today(); today.Gap || !near(today.Value, 15) {
+			t.Errorf("today: %+v, want value 15 without gap", today)
+		}
+	})
+
+	t.Run("exclude", func(t *testing.T) {
+		acc := NewDegreeDayAccumulator(HeatingDegreeDays, 18,
+			DailyMeanMethod, loc)
+		acc.GapPolicy = ExcludeGaps
+		acc.Add(before, 0)
+		acc.Add(before.Add(4*time.Hour), 4)
+		days := acc.Days()
+		if len(days) != 1 {
+			t.Fatalf("got %d completed days, want 1", len(days))
+		}
+		if !days[0].Gap || !near(days[0].Value, 18) {
+			t.Errorf("first day: %+v, want value 18 with gap", days[0])
+		}
+		if today := acc.Today(); !today.Gap || !near(today.Value, 14) {
+			t.Errorf("today: %+v, want value 14 with gap", today)
+		}
+	})
+
+	t.Run("exclude multi-day", func(t *testing.T) {
+		acc := NewDegreeDayAccumulator(HeatingDegreeDays, 18,
+			DailyMeanMethod, loc)
+		acc.GapPolicy = ExcludeGaps
+		acc.Add(before, 10)
+		after := before.Add(52 * time.Hour)
+		acc.Add(after, 10)
+		days := acc.Days()
+		if len(days) != 1 {
+			t.Fatalf("got %d completed days, want 1: %+v", len(days), days)
+		}
+		if today := acc.Today(); !today.Date.Equal(acc.dayOf(after)) {
+			t.Errorf("today: date %v, want %v", today.Date, acc.dayOf(after))
+		}
+		if total := acc.Total(); !near(total, 16) {
+			t.Errorf("total %v, want 16", total)
+		}
+	})
+}
@@ -1,10 +1,11 @@
 package dht
 
 import(
-	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
-	"errors"
 	"github.com/kidoman/embd"
 	"github.com/gavv/monotime"
 	//"unsafe"
@@ -26,6 +27,57 @@ func (this SensorType) String() string {
 	}
 }
 
+// Sensor names accepted by ParseSensorType, in lower case.
+var sensorTypeNames = []struct {
+	name       string
+	sensorType SensorType
+}{
+	{"dht11", DHT11},
+	{"dht22", DHT22},
+	{"am2302", AM2302},
+}
+
+// Convert sensor name, such as "dht11", "DHT22" or "am2302",
+// to SensorType. Names are case-insensitive.
+func ParseSensorType(s string) (SensorType, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	names := make([]string, len(sensorTypeNames))
+	for i, item := range sensorTypeNames {
+		if item.name == name {
+			return item.sensorType, nil
+		}
+		names[i] = item.name
+	}
+	return 0, fmt.Errorf("%w %q, expected one of: %s",
+		ErrUnknownSensorType, s, strings.Join(names, ", "))
+}
+
+// Implement encoding.TextMarshaler interface.
+func (this SensorType) MarshalText() ([]byte, error) {
+	for _, item := range sensorTypeNames {
+		if item.sensorType == this {
+			return []byte(item.name), nil
+		}
+	}
+	return nil, &UnknownSensorTypeError{Type: this}
+}
+
+// Implement encoding.TextUnmarshaler interface.
+func (this *SensorType) UnmarshalText(text []byte) error {
+	sensorType, err := ParseSensorType(string(text))
+	if err != nil {
+		return err
+	}
+	*this = sensorType
+	return nil
+}
+
+// Implement flag.Value interface, so sensor type could be
+// passed in command line: flag.Var(&sensorType, "type", "dht11|dht22|am2302").
+func (this *SensorType) Set(s string) error {
+	return this.UnmarshalText([]byte(s))
+}
+
 const (
 	// Most populare sensor
 	DHT11 SensorType = iota + 1
@@ -42,16 +94,20 @@ type Pulse struct {
 }
 
 // Activate sensor and get back bunch of pulses for further decoding.
-func dialDHTxxAndGetResponse(pin int, boostPerfFlag bool) ([]Pulse, error) {
-	var arr []int
+// Use *values as capture buffer, if values isn't nil, see
+// gpioReadSeqUntilTimeout.
+func dialDHTxxAndGetResponse(ctx context.Context, p embd.DigitalPin,
+	boostPerfFlag bool, signal startSignal, limits captureLimits,
+	values *[]int64) (Pulses, error) {
+	var arr []int64
 	//var list []int
 	var boost int = 0
 	if boostPerfFlag {
 		boost = 1
 	}
 
-	// Return array: [pulse, duration, pulse, duration, ...]
-	err := dialDHTxxAndRead(int(pin), boost, &arr)
+	// Return array: [pulse, duration in nanoseconds, pulse, duration, ...]
+	err := dialDHTxxAndRead(ctx, p, boost, signal, limits, values, &arr)
 	if err != nil {
 		//err := fmt.Errorf("Error during call C.dial_DHTxx_and_read()")
 		return nil, err
@@ -63,7 +119,7 @@ func dialDHTxxAndGetResponse(pin int, boostPerfFlag bool) ([]Pulse, error) {
 	//h.Len = int(arrLen)
 	//h.Cap = int(arrLen)
 	//pulses := make([]Pulse, len(list)/2)
-	pulses := make([]Pulse, len(arr)/2)
+	pulses := make(Pulses, len(arr)/2)
 	// Convert original int array ([pulse, duration, pulse, duration, ...])
 	// to Pulse struct array
 	//for i := 0; i < len(list)/2; i++ {
@@ -75,120 +131,350 @@ func dialDHTxxAndGetResponse(pin int, boostPerfFlag bool) ([]Pulse, error) {
 		}
 		pulses[i] = Pulse{Value: value,
 			//Duration: time.Duration(list[i*2+1]) * time.Microsecond}
-			Duration: time.Duration(arr[i*2+1]) * time.Microsecond}
+			Duration: time.Duration(arr[i*2+1])}
 	}
 	return pulses, nil
 }
 
-// TODO write comment to function
+// Decode 8 bits starting from pulse with index start.
+// Each bit is a pair of low and high pulses,
+// where length of high pulse tell 0 from 1.
 func decodeByte(pulses []Pulse, start int) (byte, error) {
 	if len(pulses)-start < 16 {
-		return 0, fmt.Errorf("Can't decode byte, since range between "+
-			"index and array length is less than 16: %d, %d", start, len(pulses))
+		return 0, &PulseCountError{Count: len(pulses)}
 	}
 	var b int = 0
 	for i := 0; i < 8; i++ {
-		pulseL := pulses[start+i*2]
-		pulseH := pulses[start+i*2+1]
-		if pulseL.Value != 0 {
-			return 0, fmt.Errorf("Low edge value expected at index %d", start+i*2)
-		}
-		if pulseH.Value == 0 {
-			return 0, fmt.Errorf("High edge value expected at index %d", start+i*2+1)
-		}
-		const HIGH_DUR_MAX = (70 + (70 + 54)) / 2 * time.Microsecond
-		// Calc average value between 24us (bit 0) and 70us (bit 1).
-		// Everything that less than this param is bit 0, bigger - bit 1.
-		const HIGH_DUR_AVG = (24 + (70-24)/2) * time.Microsecond
-		if pulseH.Duration > HIGH_DUR_MAX {
-			return 0, fmt.Errorf("High edge value duration %v exceed "+
-				"expected maximum amount %v", pulseH.Duration, HIGH_DUR_MAX)
-		}
-		if pulseH.Duration > HIGH_DUR_AVG {
-			//fmt.Printf("bit %d is high\n", 7-i)
-			b = b | (1 << uint(7-i))
+		bit, err := decodeBit(pulses, start+i*2)
+		if err != nil {
+			return 0, err
 		}
+		b = b | int(bit)<<uint(7-i)
 	}
 	return byte(b), nil
 }
 
-// Decode bunch of pulse read from DHTxx sensors.
-// Use pdf specifications from /docs folder to read 5 bytes and
-// convert them to temperature and humidity.
-func decodeDHT11Pulses(sensorType SensorType, pulses []Pulse) (temperature float32,
-	humidity float32, err error) {
-	if len(pulses) == 85 {
-		pulses = pulses[3:]
-	} else if len(pulses) == 84 {
-		pulses = pulses[2:]
-	} else if len(pulses) == 83 {
-		pulses = pulses[1:]
-	} else if len(pulses) != 82 {
-		printPulseArrayForDebug(pulses)
-		return -1, -1, fmt.Errorf("Can't decode pulse array received from "+
-			"DHTxx sensor, since incorrect length: %d", len(pulses))
+// Decode single bit from pair of low and high pulses starting at index.
+func decodeBit(pulses []Pulse, index int) (byte, error) {
+	pulseL := pulses[index]
+	pulseH := pulses[index+1]
+	if pulseL.Value != 0 {
+		return 0, &PulseError{Index: index, Pulse: pulseL}
 	}
-	pulses = pulses[:80]
-	// Decode 1st byte
-	b0, err := decodeByte(pulses, 0)
-	if err != nil {
-		return -1, -1, err
+	if pulseH.Value == 0 {
+		return 0, &PulseError{Index: index + 1, Pulse: pulseH}
+	}
+	const HIGH_DUR_MAX = (70 + (70 + 54)) / 2 * time.Microsecond
+	// Calc average value between 24us (bit 0) and 70us (bit 1).
+	// Everything that less than this param is bit 0, bigger - bit 1.
+	const HIGH_DUR_AVG = (24 + (70-24)/2) * time.Microsecond
+	if pulseH.Duration > HIGH_DUR_MAX {
+		return 0, &PulseError{Index: index + 1, Pulse: pulseH,
+			MaxDuration: HIGH_DUR_MAX}
 	}
-	// Decode 2nd byte
-	b1, err := decodeByte(pulses, 16)
+	if pulseH.Duration > HIGH_DUR_AVG {
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// Decode pulses captured from DHTxx sensor, for instance by logic analyzer
+// or saved earlier, exactly as it's done for live reads.
+// Returned reading has no pin number.
+func DecodePulses(sensorType SensorType, pulses []Pulse) (Reading, error) {
+	return decodePulses(sensorType, pulses, &config{logger: getLogger()})
+}
+
+// Decode pulses, apply calibration, self-heating compensation
+// and clamp humidity above 100% according to cfg.
+func decodePulses(sensorType SensorType, pulses []Pulse,
+	cfg *config) (Reading, error) {
+	tempDeci, humDeci, err := decodeDHT11Pulses(sensorType, pulses, cfg)
 	if err != nil {
-		return -1, -1, err
+		return Reading{}, err
+	}
+	// Float values are derived from exact ones reported by sensor
+	rawTemp, rawHum := float32(tempDeci)/10, float32(humDeci)/10
+	temp, hum := rawTemp, rawHum
+	if c := cfg.calibration; c != nil {
+		temp = rawTemp*c.tempScale + c.tempOffset
+		hum = rawHum*c.humScale + c.humOffset
 	}
-	// Decode 3rd byte
-	b2, err := decodeByte(pulses, 32)
+	compensated := false
+	if cfg.selfHeating > 0 {
+		temp, hum = compensateSelfHeating(temp, hum, cfg.selfHeating)
+		compensated = true
+	}
+	clamped := false
+	if hum > 100 && hum <= cfg.clampHumidity {
+		hum = 100
+		clamped = true
+	}
+	reading := Reading{Temperature: Temperature(temp),
+		Humidity: Humidity(hum), RawTemperature: Temperature(rawTemp),
+		RawHumidity: Humidity(rawHum), Clamped: clamped,
+		Compensated: compensated, Time: time.Now(), Sensor: sensorType}
+	if cfg.calibration == nil && !compensated && !clamped {
+		reading.exact = true
+		reading.temperatureDeci, reading.humidityDeci = tempDeci, humDeci
+	}
+	// Humidity above 100% is never valid, whatever sensor is
+	if err := checkRange(reading, "humidity", hum, 0, 100); err != nil {
+		return Reading{}, err
+	}
+	reading.markOutOfSpec()
+	return reading, nil
+}
+
+// Decode pulses as decodePulses does, then check values against
+// sensor type measurement range, if cfg require it.
+func decodeChecked(sensorType SensorType, pulses []Pulse,
+	cfg *config) (Reading, error) {
+	reading, err := decodePulses(sensorType, pulses, cfg)
 	if err != nil {
-		return -1, -1, err
+		return Reading{}, err
+	}
+	if cfg.strictRange {
+		if err := reading.Validate(); err != nil {
+			return Reading{}, err
+		}
 	}
-	// Decode 4th byte
-	b3, err := decodeByte(pulses, 48)
+	return reading, nil
+}
+
+// Decode bunch of pulse read from DHTxx sensors.
+// Use pdf specifications from /docs folder to read 5 bytes and
+// convert them to temperature and humidity in tenths.
+func decodeDHT11Pulses(sensorType SensorType, pulses []Pulse,
+	cfg *config) (temperature int16, humidity uint16, err error) {
+	b, err := decodeBytes(pulses, cfg.strictFrame, cfg.logger)
 	if err != nil {
-		return -1, -1, err
+		return 0, 0, err
 	}
-	// Decode 5th byte: control sum to verify all data received from sensor
-	sum, err := decodeByte(pulses, 64)
+	// Debug output for 5 bytes
+	logDebug(cfg.logger, "Five bytes from DHTxx", "sensor", sensorType,
+		"bytes", b)
+	return convertBytes(sensorType, b, cfg.negativeEncoding)
+}
+
+// Extract 5 bytes sent by DHTxx sensor from pulses and verify control sum.
+// In case of control sum mismatch bytes are returned with ChecksumError.
+func decodeBytes(captured []Pulse, strictFrame bool,
+	l Logger) ([5]byte, error) {
+	var b [5]byte
+	pulses, err := alignFrame(captured, strictFrame, l)
 	if err != nil {
-		return -1, -1, err
+		return b, err
+	}
+	// Decode 4 data bytes and 5th byte: control sum
+	// to verify all data received from sensor
+	for i := range b {
+		var err error
+		b[i], err = decodeByte(pulses, i*16)
+		if err != nil {
+			return [5]byte{}, err
+		}
 	}
 	// Produce data integrity check
-	if sum != byte(b0+b1+b2+b3) {
-		err := fmt.Errorf("Control sum %d doesn't match %d (%d+%d+%d+%d)",
-			sum, byte(b0+b1+b2+b3), b0, b1, b2, b3)
-		return -1, -1, err
+	if sum := b[0] + b[1] + b[2] + b[3]; b[4] != sum {
+		// Copy pulses, since they may share memory with capture buffer
+		err := &ChecksumError{Bytes: b, Expected: sum,
+			Pulses: append(Pulses(nil), captured...)}
+		return b, err
 	}
-	// Debug output for 5 bytes
-	log.Debug("Five bytes from DHTxx: [%d, %d, %d, %d, %d]", b0, b1, b2, b3, sum)
-	// Extract temprature and humidity depending on sensor type
-	temperature, humidity = 0.0, 0.0
-	if sensorType == DHT11 {
-		humidity = float32(b0)
-		temperature = float32(b2)
-	} else if sensorType == DHT22 {
-		humidity = (float32(b0)*256 + float32(b1)) / 10.0
-		temperature = (float32(b2&0x7F)*256 + float32(b3)) / 10.0
-		if b2&0x80 != 0 {
-			temperature *= -1.0
+	return b, nil
+}
+
+// Bounds of 80us low and high pulses sensor respond with
+// to start signal, before data bits.
+const (
+	preambleDurMin = 60 * time.Microsecond
+	preambleDurMax = 100 * time.Microsecond
+)
+
+// Return true if pair of pulses looks like response preamble.
+func isPreamble(pulseL, pulseH Pulse) bool {
+	return pulseL.Value == 0 && pulseH.Value != 0 &&
+		pulseL.Duration >= preambleDurMin &&
+		pulseL.Duration <= preambleDurMax &&
+		pulseH.Duration >= preambleDurMin &&
+		pulseH.Duration <= preambleDurMax
+}
+
+// Return index of first response preamble followed by
+// at least 80 pulses, or -1 if there is none.
+func findPreamble(pulses []Pulse) int {
+	for i := 0; i+2+80 <= len(pulses); i++ {
+		if isPreamble(pulses[i], pulses[i+1]) {
+			return i
 		}
 	}
-	if humidity > 100.0 {
-		return -1, -1, fmt.Errorf("Humidity value exceed 100%%: %v", humidity)
+	return -1
+}
+
+// Find 80 pulses carrying 40 data bits in captured pulses.
+// Frame follows response preamble, wherever it is, so junk pulses
+// around are ignored. If preamble isn't found or strict is set,
+// frame position is derived from pulse count. Pulses which can't be
+// aligned at all are dumped to l.
+func alignFrame(pulses []Pulse, strict bool, l Logger) ([]Pulse, error) {
+	if len(pulses) < 2 {
+		return nil, ErrNoResponse
+	}
+	if !strict {
+		if i := findPreamble(pulses); i >= 0 {
+			return pulses[i+2 : i+2+80], nil
+		}
+	}
+	// With 84 or 85 pulses preamble is captured entirely,
+	// make sure it's preamble indeed
+	if len(pulses) == 85 || len(pulses) == 84 {
+		i := len(pulses) - 84
+		if !isPreamble(pulses[i], pulses[i+1]) {
+			return nil, &PreambleError{Low: pulses[i], High: pulses[i+1],
+				MinDuration: preambleDurMin, MaxDuration: preambleDurMax}
+		}
+	}
+	if len(pulses) == 85 {
+		pulses = pulses[3:]
+	} else if len(pulses) == 84 {
+		pulses = pulses[2:]
+	} else if len(pulses) == 83 {
+		pulses = pulses[1:]
+	} else if len(pulses) != 82 {
+		printPulseArrayForDebug(l, pulses)
+		return nil, &PulseCountError{Count: len(pulses)}
+	}
+	return pulses[:80], nil
+}
+
+// Convert 5 bytes received from sensor to temperature and humidity
+// in tenths of degree and percent.
+func convertBytes(sensorType SensorType, b [5]byte,
+	encoding NegativeEncoding) (temperature int16, humidity uint16, err error) {
+	// Extract temprature and humidity depending on sensor type
+	switch sensorType {
+	case DHT11:
+		// Newer DHT11 firmware report tenths in 2nd and 4th bytes,
+		// older one send zeros there
+		humidity = uint16(b[0])*10 + uint16(b[1])
+		temperature = int16(b[2])*10 + int16(b[3]&0x0F)
+		if b[3]&0x80 != 0 {
+			temperature = -temperature
+		}
+	case DHT22:
+		humidity = uint16(b[0])<<8 | uint16(b[1])
+		temperature = decodeDHT22Temperature(b[2], b[3], encoding)
+	default:
+		return 0, 0, &UnknownSensorTypeError{Type: sensorType}
 	}
 	// Success
 	return temperature, humidity, nil
 }
 
-// Print bunch of pulses for debug purpose.
-func printPulseArrayForDebug(pulses []Pulse) {
-	var buf bytes.Buffer
-	for i, pulse := range pulses {
-		buf.WriteString(fmt.Sprintf("pulse %3d: %v, %v\n", i,
-			pulse.Value, pulse.Duration))
+// Way DHT22 clones encode negative temperature.
+type NegativeEncoding int
+
+const (
+	// Highest bit is sign, the rest is magnitude, as genuine
+	// Aosong sensors do.
+	SignMagnitude NegativeEncoding = iota
+	// 16-bit two's complement, as many cheap AM2302 clones do.
+	TwosComplement
+	// Sign-magnitude, unless magnitude is implausibly large:
+	// then two's complement.
+	AutoDetect
+)
+
+// Decode DHT22 temperature in tenths of degree from high and low bytes.
+func decodeDHT22Temperature(hi, lo byte, encoding NegativeEncoding) int16 {
+	magnitude := int16(hi&0x7F)<<8 | int16(lo)
+	if hi&0x80 == 0 {
+		return magnitude
 	}
-	log.Debug("Pulse count %d:\n%v", len(pulses), buf.String())
+	// DHT22 can't measure below -40°C
+	if encoding == TwosComplement || encoding == AutoDetect && magnitude > 400 {
+		return int16(uint16(hi)<<8 | uint16(lo))
+	}
+	return -magnitude
+}
+
+// Print bunch of pulses for debug purpose,
+// only if l has debug output enabled.
+func printPulseArrayForDebug(l Logger, pulses Pulses) {
+	if !debugEnabled(l) {
+		return
+	}
+	logDebug(l, "Pulses captured", "pulse_count", len(pulses),
+		"pulses", pulses)
+}
+
+// Send activation request to DHTxx sensor via specific pin,
+// decode response and return temperature and humidity.
+// Behavior is tuned with options, for instance:
+//
+//	reading, err := dht.Read(dht.DHT22, 4, dht.WithRetries(5),
+//		dht.WithRetryDelay(2*time.Second))
+//
+// Invalid options are reported as error before GPIO is touched.
+// GPIO is initialized for the read and closed after it (unless opened
+// Sensor holds it), closing other pins opened through embd as well.
+// Pass WithExternalGPIO, if application use other pins.
+func Read(sensorType SensorType, pin int, opts ...Option) (Reading, error) {
+	return ReadContext(context.Background(), sensorType, pin, opts...)
+}
+
+// Same as Read, but stop as soon as ctx is done.
+func ReadContext(ctx context.Context, sensorType SensorType, pin int,
+	opts ...Option) (Reading, error) {
+	reading, _, err := readPin(ctx, sensorType, pin, opts)
+	return reading, err
+}
+
+// Read sensor connected to pin opened by caller. GPIO is neither
+// initialized nor closed, pin is left opened.
+func ReadFromPin(sensorType SensorType, p embd.DigitalPin,
+	opts ...Option) (Reading, error) {
+	return ReadFromPinContext(context.Background(), sensorType, p, opts...)
+}
+
+// Same as ReadFromPin, but stop as soon as ctx is done.
+func ReadFromPinContext(ctx context.Context, sensorType SensorType,
+	p embd.DigitalPin, opts ...Option) (Reading, error) {
+	sensor, err := NewSensorFromPin(sensorType, p, opts...)
+	if err != nil {
+		return Reading{}, err
+	}
+	defer sensor.Close()
+	reading, _, err := sensor.readWithRetry(ctx)
+	return reading, err
+}
+
+// Open throwaway Sensor for single read.
+// Return reading with number of extra retries made.
+func readPin(ctx context.Context, sensorType SensorType, pin int,
+	opts []Option) (Reading, int, error) {
+	sensor, err := NewSensor(sensorType, pin, opts...)
+	if err != nil {
+		return Reading{}, 0, err
+	}
+	defer sensor.Close()
+	return sensor.readWithRetry(ctx)
+}
+
+// Send activation request to DHTxx sensor via specific pin and return
+// 5 raw bytes of response: 4 data bytes and control sum, skipping
+// conversion to temperature and humidity. Useful to diagnose sensors
+// with non-standard encoding. Control sum mismatch is reported with
+// ChecksumError, but bytes are returned anyway.
+// GPIO is closed after read, as ReadDHTxx does.
+func ReadRaw(sensorType SensorType, pin int, boostPerfFlag bool) ([5]byte, error) {
+	sensor, err := NewSensor(sensorType, pin, legacyOptions(boostPerfFlag, 0)...)
+	if err != nil {
+		return [5]byte{}, err
+	}
+	defer sensor.Close()
+	return sensor.ReadRaw()
 }
 
 // Send activation request to DHTxx sensor via specific pin.
@@ -204,27 +490,35 @@ func printPulseArrayForDebug(pulses []Pulse) {
 // Return:
 // 1) temperature in Celsius;
 // 2) humidity in percent;
-// 3) error if present.
+// 3) error if present (temperature and humidity are zero in this case).
+//
+// GPIO is closed after every read (unless opened Sensor holds it), closing
+// other pins opened through embd as well. Application using other pins
+// should call Read with WithExternalGPIO or ReadFromPin instead.
 func ReadDHTxx(sensorType SensorType, pin int,
 	boostPerfFlag bool) (temperature float32, humidity float32, err error) {
-	// Activate sensor and read data to pulses array
-	pulses, err := dialDHTxxAndGetResponse(pin, boostPerfFlag)
-	if err != nil {
-		return -1, -1, err
-	}
-	// Output debug information
-	printPulseArrayForDebug(pulses)
-	// Decode pulses
-	temp, hum, err := decodeDHT11Pulses(sensorType, pulses)
+	return ReadDHTxxContext(context.Background(), sensorType, pin,
+		boostPerfFlag)
+}
+
+// Same as ReadDHTxx, but stop as soon as ctx is done. Context is checked
+// between activation phases and periodically while sampling pin,
+// so cancellation return error wrapping ctx.Err().
+func ReadDHTxxContext(ctx context.Context, sensorType SensorType, pin int,
+	boostPerfFlag bool) (temperature float32, humidity float32, err error) {
+	reading, _, err := readPin(ctx, sensorType, pin,
+		legacyOptions(boostPerfFlag, 0))
 	if err != nil {
-		return -1, -1, err
+		return 0, 0, err
 	}
-	return temp, hum, nil
+	return reading.Temperature.Celsius(), reading.Humidity.Percent(), nil
 }
 
 // Send activation request to DHTxx sensor via specific pin.
 // Then decode pulses sent back with asynchronous
 // protocol specific for DHTxx sensors. Retry n times in case of failure.
+// Only transient errors are retried (see IsTransient), GPIO and permission
+// errors are returned immediately.
 //
 // Input parameters:
 // 1) sensor type: DHT11, DHT22 (aka AM2302);
@@ -237,48 +531,128 @@ func ReadDHTxx(sensorType SensorType, pin int,
 // 1) temperature in Celsius;
 // 2) humidity in percent;
 // 3) number of extra retries data from sensor;
-// 4) error if present.
+// 4) error if present (temperature and humidity are zero in this case).
+//
+// GPIO is closed after every call, as ReadDHTxx does.
 func ReadDHTxxWithRetry(sensorType SensorType, pin int, boostPerfFlag bool,
 	retry int) (temperature float32, humidity float32, retried int, err error) {
-	retried = 0
-	for {
-		temp, hum, err := ReadDHTxx(sensorType, pin, boostPerfFlag)
-		if err != nil {
-			if retry > 0 {
-				log.Warning("%v", err)
-				retry--
-				retried++
-				// Sleep before new attempt
-				time.Sleep(1500 * time.Millisecond)
-				continue
-			}
-			return -1, -1, retried, err
-		}
-		return temp, hum, retried, nil
+	return ReadDHTxxWithRetryContext(context.Background(), sensorType, pin,
+		boostPerfFlag, retry)
+}
+
+// Same as ReadDHTxxWithRetry, but stop as soon as ctx is done,
+// including the pause between attempts.
+func ReadDHTxxWithRetryContext(ctx context.Context, sensorType SensorType,
+	pin int, boostPerfFlag bool, retry int) (temperature float32,
+	humidity float32, retried int, err error) {
+	reading, retried, err := readPin(ctx, sensorType, pin,
+		legacyOptions(boostPerfFlag, retry))
+	if err != nil {
+		return 0, 0, retried, err
+	}
+	return reading.Temperature.Celsius(), reading.Humidity.Percent(),
+		retried, nil
+}
+
+// Build options equivalent to ReadDHTxx... functions parameters.
+// Legacy functions wait for minimum interval between reads
+// rather than fail, so existing polling loops keep working.
+func legacyOptions(boostPerfFlag bool, retry int) []Option {
+	opts := []Option{WithMinIntervalWait()}
+	if boostPerfFlag {
+		// Boost was never applied by earlier versions,
+		// so don't fail where it worked before
+		opts = append(opts, WithBoost(), WithBoostFallback())
+	}
+	if retry > 0 {
+		opts = append(opts, WithRetries(retry))
+	}
+	return opts
+}
+
+// Monotonic clock pulses are timed with. Replaced by tests
+// with virtual clock of mock pin.
+var now = monotime.Now
+
+// Pause between reads: retry delay and minimum interval.
+// Replaced by tests with fake clock.
+var pause = sleepContext
+
+// Sleep for duration d, or less if ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return contextError(ctx)
+	case <-timer.C:
+		return nil
 	}
 }
 
-func gpioReadSeqUntilTimeout(p embd.DigitalPin, timeoutMsec int,
-		arr *[]int) error {
+// Wrap context error to report that read was interrupted.
+func contextError(ctx context.Context) error {
+	return fmt.Errorf("Read from DHTxx sensor interrupted: %w", ctx.Err())
+}
+
+// Initial amount of level changes capture buffer keeps,
+// buffer grows when more is needed.
+const maxPulseCount = 16000
+
+// Sensor should respond in 20-40us after line is released, pulling
+// it low for 80us. Line without edges that long after release means
+// either no sensor (line stays low) or no response (line stays high).
+const responseTimeout = 2 * time.Millisecond
+
+// Number of unchanged samples in a row, required along with timeout
+// to consider line idle.
+const minIdleSamples = 20
+
+// Bounds of single capture, zero means no limit.
+type captureLimits struct {
+	// Maximum number of pin reads.
+	maxSamples int
+	// Maximum capture duration.
+	maxDuration time.Duration
+}
+
+// Sample pin until its level stay unchanged longer than timeoutMsec,
+// or any of limits is exceeded.
+// Fill arr with [level, duration in nanoseconds, level, duration, ...].
+// Use *values as capture buffer, if it is big enough, buffer grown
+// for long capture is stored back for next one (values may be nil).
+func gpioReadSeqUntilTimeout(ctx context.Context, p embd.DigitalPin,
+		timeoutMsec int, limits captureLimits, values *[]int64,
+		arr *[]int64) error {
 	var nextT time.Duration
 	var lastT time.Duration
 
 	var nextV int
 
-	maxPulseCount := 16000
 	//var values [maxPulseCount * 2]int
-	var values = make([]int64, maxPulseCount * 2)
+	var buf []int64
+	if values != nil {
+		buf = *values
+		defer func() {
+			*values = buf
+		}()
+	}
+	if len(buf) < maxPulseCount*2 {
+		buf = make([]int64, maxPulseCount*2)
+	}
 
 	lastV, err := p.Read()
 	if err != nil {
-		fmt.Println("Failed to read value!")
-		return err
+		return newGPIOError(err, "read pin %d value", p.N())
 	}
 
 	k, i := 0, 0
-	values[k*2] = int64(lastV)
+	samples := 1
+	buf[k*2] = int64(lastV)
 
-	lastT = monotime.Now()
+	lastT = now()
+	startT := lastT
+	timeout := time.Duration(timeoutMsec) * time.Millisecond
 
 	for {
 		// Because declarations
@@ -286,44 +660,64 @@ func gpioReadSeqUntilTimeout(p embd.DigitalPin, timeoutMsec int,
 
 		nextV, err = p.Read()
 		if err != nil {
-			fmt.Println("Failed to read value!")
-			return err
+			return newGPIOError(err, "read pin %d value", p.N())
+		}
+		samples++
+
+		// Observe cancellation and capture limits every few milliseconds
+		if samples%100 == 0 {
+			if ctx.Err() != nil {
+				return contextError(ctx)
+			}
+			elapsed := now() - startT
+			if limits.maxSamples > 0 && samples >= limits.maxSamples ||
+				limits.maxDuration > 0 && elapsed > limits.maxDuration {
+				return &CaptureTruncatedError{Samples: samples, Edges: k,
+					BufferSize: len(buf) / 2, Elapsed: elapsed}
+			}
 		}
 
+		nextT = now()
+
 		if lastV != nextV {
-			nextT = monotime.Now()
 			i = 0
 			k++
 
-			if (k > maxPulseCount - 1) {
-				return errors.New(fmt.Sprintf("Pulse count exceed limit in %d\n",
-					maxPulseCount))
+			// Grow buffer, keeping room for final duration
+			if k*2+1 >= len(buf) {
+				buf = append(buf, make([]int64, len(buf))...)
 			}
 
-			values[k*2] = int64(nextV)
-			values[k*2-1] = nextT.Nanoseconds() / int64(1000) - lastT.Nanoseconds() / int64(1000)
+			buf[k*2] = int64(nextV)
+			buf[k*2-1] = int64(nextT - lastT)
 
 			lastV = nextV
 			lastT = nextT
 		}
 
-		if i > 20 {
-			nextT = monotime.Now()
-
-			if (nextT.Nanoseconds() / int64(1000) - lastT.Nanoseconds() / int64(1000)) / 1000 > int64(timeoutMsec) {
-				values[k*2+1] = int64(timeoutMsec * 1000)
-				break
+		// Fail fast, instead of waiting for idle line, once line didn't
+		// change at all. Edges are checked first, so late sample
+		// (thread was descheduled) doesn't hide response of present sensor.
+		if k == 0 && nextT - startT > responseTimeout {
+			if nextV == 0 {
+				return ErrNoSensor
 			}
+			return ErrNoResponse
+		}
+
+		// Line is idle, once its level stays unchanged longer than timeout
+		// since last edge. Also require few unchanged samples in a row,
+		// so single late sample (thread was descheduled right after edge)
+		// doesn't end capture too early.
+		if i >= minIdleSamples && nextT - lastT > timeout {
+			buf[k*2+1] = int64(timeout)
+			break
 		}
 		i++
 	}
 
-	(*arr) = make([]int, (k+1)*2)
-
-	for i = 0; i <= k; i++ {
-		(*arr)[i*2] = int(values[i*2])
-		(*arr)[i*2+1] = int(values[i*2+1])
-	}
+	(*arr) = make([]int64, (k+1)*2)
+	copy(*arr, buf[:(k+1)*2])
 
 	return nil
 }
@@ -337,12 +731,19 @@ func gpioReadSeqUntilTimeout(p embd.DigitalPin, timeoutMsec int,
  *	so it doesn't really hurt to include it in the odd case that someone is
  *  actually using it
  */
-func blinkNTimes(pin int, n int) error {
-	if err := embd.InitGPIO(); err != nil { return err }
-	defer embd.CloseGPIO()
+func blinkNTimes(pin int, n int) (err error) {
+	if err := acquireGPIO(); err != nil { return err }
+	defer func() {
+		err = errors.Join(err, releaseGPIO())
+	}()
 
-	p, err := embd.NewDigitalPin(pin)
+	p, err := newDigitalPin(pin)
 	if err != nil { return err }
+	defer func() {
+		if closeErr := p.Close(); closeErr != nil {
+			err = errors.Join(err, newGPIOError(closeErr, "close pin %d", pin))
+		}
+	}()
 
 	if err := p.SetDirection(embd.Out); err != nil { return err }
 
@@ -361,70 +762,86 @@ func blinkNTimes(pin int, n int) error {
 	return nil
 }
 
-// TODO:  Convert all referenced C functions and variables
-func dialDHTxxAndRead(pin int, boostPerfFlag int, arr *[]int) error {
-	// TODO:  Transcode function setMaxPriority
-	/*if boostPerfFlag != false; err := setMaxPriority(); err != nil {
-		return -1
-	}*/
+// Send start signal to DHTxx sensor via pin p and sample response to arr.
+// Pin should be opened by caller and stay opened.
+func dialDHTxxAndRead(ctx context.Context, p embd.DigitalPin,
+	boostPerfFlag int, signal startSignal, limits captureLimits,
+	values *[]int64, arr *[]int64) (err error) {
+	if ctx.Err() != nil {
+		return contextError(ctx)
+	}
 
-	// Initialize the GPIO interface
-	if err := embd.InitGPIO(); err != nil {
-		// TODO:  Transcode function setDefaultPriority
-		//setDefaultPriority()
-		return err
+	// Raise priority for handshake and capture to reduce timing jitter
+	if boostPerfFlag != 0 {
+		save, perr := raisePriority()
+		if perr != nil {
+			return perr
+		}
+		// Assign named err, so failed restore isn't lost
+		defer func() {
+			err = errors.Join(err, restorePriority(save))
+		}()
 	}
-    defer embd.CloseGPIO()
 
-	// Open pin
-	p, err := embd.NewDigitalPin(pin)
-	if err != nil {
-		//setDefaultPriority()
+	if err := sendStartSignal(ctx, p, signal); err != nil {
+		// Don't leave data line driven, otherwise sensor is blocked
+		// until next successful start signal
+		if relErr := p.SetDirection(embd.In); relErr != nil {
+			err = errors.Join(err,
+				newGPIOError(relErr, "release pin %d", p.N()))
+		}
 		return err
 	}
-	defer p.Close()
 
+	// Read data from sensor
+	return gpioReadSeqUntilTimeout(ctx, p, 10, limits, values, arr)
+}
+
+// Timing of start signal sent to sensor.
+type startSignal struct {
+	// How long line is held high before start signal.
+	hold time.Duration
+	// How long line is pulled low: DHT11 datasheet require 18 milliseconds,
+	// while AM2302 expect 0.8-20 milliseconds.
+	low time.Duration
+}
+
+// Send start signal: hold line high, then low according to signal,
+// and release it to receive response.
+func sendStartSignal(ctx context.Context, p embd.DigitalPin,
+	signal startSignal) error {
 	// Set pin out for dial pulse
 	if err := p.SetDirection(embd.Out); err != nil {
-		//setDefaultPriority()
-		return err
+		return newGPIOError(err, "set pin %d direction", p.N())
 	}
 
 	// Set pin to high
 	if err := p.Write(embd.High); err != nil {
-		//setDefaultPriority()
-		return err
+		return newGPIOError(err, "set pin %d high", p.N())
 	}
 
-	// Sleep 500 milliseconds
-	time.Sleep(500 * time.Millisecond)
+	// Let line settle in high state
+	if signal.hold > 0 {
+		if err := sleepContext(ctx, signal.hold); err != nil {
+			return err
+		}
+	}
 
 	// Set pin to low
 	if err := p.Write(embd.Low); err != nil {
-		//setDefaultPriority()
-		return err
+		return newGPIOError(err, "set pin %d low", p.N())
 	}
 
-	// Sleep 18 milliseconds according to DHTxx specification
-	time.Sleep(18 * time.Millisecond)
+	// Sleep according to DHTxx specification
+	time.Sleep(signal.low)
 
-	// Set pin in to receive dial response
-	if err := p.SetDirection(embd.In); err != nil {
-		//setDefaultPriority()
-		return err
+	if ctx.Err() != nil {
+		return contextError(ctx)
 	}
 
-	// Read data from sensor
-	// TODO:  Transcode function gpioReadSeqUntilTimeout
-	if err := gpioReadSeqUntilTimeout(p, 10, arr); err != nil {
-		//setDefaultPriority()
-		return err
+	// Set pin in to receive dial response
+	if err := p.SetDirection(embd.In); err != nil {
+		return newGPIOError(err, "set pin %d direction", p.N())
 	}
-
-	/*if boostPerfFlag != false; err := setDefaultPriority(); err != nil {
-		setDefaultPriority()
-		return err
-	}*/
-
 	return nil
 }
@@ -0,0 +1,661 @@
+package dht
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kidoman/embd"
+)
+
+// Options making live reads from mock pin quick.
+func quickOptions(opts ...Option) []Option {
+	return append([]Option{WithStartHold(0),
+		WithRetryDelay(time.Millisecond)}, opts...)
+}
+
+func TestReadNoSensor(t *testing.T) {
+	tests := []struct {
+		name string
+		idle int
+		want error
+		kind ErrorKind
+		// Captures expected with 2 retries allowed.
+		captures int
+	}{
+		{"stuck low", 0, ErrNoSensor, KindNoSensor, 1},
+		{"stuck high", 1, ErrNoResponse, KindNoResponse, 3},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pin := newMockPin(nil, test.idle)
+			sensor, err := NewSensorFromPin(DHT22, pin,
+				quickOptions(WithRetries(2))...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			start := time.Now()
+			_, err = sensor.Read()
+			if !errors.Is(err, test.want) {
+				t.Fatalf("got %v, want %v", err, test.want)
+			}
+			if Kind(err) != test.kind {
+				t.Errorf("kind %v, want %v", Kind(err), test.kind)
+			}
+			// Each capture fail fast, instead of waiting for idle line
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("read took %v", elapsed)
+			}
+			if n := pin.count("Write") / 2; n != test.captures {
+				t.Errorf("%d captures, want %d", n, test.captures)
+			}
+		})
+	}
+}
+
+// Capture with fewer transitions than a frame ends with typed error,
+// not with index out of range.
+func TestReadFewTransitions(t *testing.T) {
+	good := responseWave(dht22Bytes(452, 213))
+	tests := []struct {
+		name string
+		wave []level
+		idle int
+		want error
+		kind ErrorKind
+		// Pulses captured, if decoding failed.
+		count int
+	}{
+		{"quiet line", nil, 1, ErrNoResponse, KindNoResponse, 0},
+		{"quiet low line", nil, 0, ErrNoSensor, KindNoSensor, 0},
+		{"single edge", good[:1], 0, ErrBadPulseCount, KindTooFewPulses, 2},
+		{"preamble only", good[:3], 1, ErrBadPulseCount, KindTooFewPulses, 3},
+		{"truncated frame", good[:3+40], 1, ErrBadPulseCount,
+			KindTooFewPulses, 43},
+		{"truncated low", good[:3+41], 0, ErrBadPulseCount,
+			KindTooFewPulses, 44},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := readMock(test.wave, test.idle, WithRetries(0))
+			if !errors.Is(err, test.want) || Kind(err) != test.kind {
+				t.Fatalf("got %v of kind %v, want %v of kind %v", err,
+					Kind(err), test.want, test.kind)
+			}
+			var countErr *PulseCountError
+			if test.count > 0 && (!errors.As(err, &countErr) ||
+				countErr.Count != test.count) {
+				t.Errorf("got %#v, want %d pulses", err, test.count)
+			}
+		})
+	}
+}
+
+// Capture of noisy line ends within duration limit, whatever noise
+// frequency is, while idle line ends it once timeout passed since
+// the last edge.
+func TestCaptureTermination(t *testing.T) {
+	const limit = 50 * time.Millisecond
+	const timeout = 10 * time.Millisecond
+	// Limits are checked every 100 samples, few more samples are taken
+	// by start signal
+	const margin = 200 * mockReadTime
+	// Sensor pulls line low as usual, but then line toggles with period
+	noise := func(period time.Duration) []level {
+		wave := []level{{1, 30 * time.Microsecond}}
+		for value := 0; time.Duration(len(wave))*period < time.Second; value ^= 1 {
+			wave = append(wave, level{value, period})
+		}
+		return wave
+	}
+	good := responseWave(dht22Bytes(452, 213))
+	var goodDuration time.Duration
+	for _, l := range good {
+		goodDuration += l.dur
+	}
+	longLast := append(append([]level{}, good...), level{1, 30 * time.Microsecond},
+		level{0, timeout - time.Millisecond})
+	tests := []struct {
+		name     string
+		wave     []level
+		want     error
+		min, max time.Duration
+	}{
+		{"fast noise", noise(10 * time.Microsecond), ErrCaptureTruncated,
+			limit, limit + margin},
+		{"slow noise", noise(timeout / 2), ErrCaptureTruncated,
+			limit, limit + margin},
+		{"noise slower than timeout", noise(2 * timeout), ErrBadPulseCount,
+			timeout, timeout + margin},
+		{"idle after response", good, nil, goodDuration + timeout,
+			goodDuration + timeout + margin},
+		{"long last pulse", longLast, nil,
+			goodDuration + 2*timeout - time.Millisecond,
+			goodDuration + 2*timeout + margin},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sensor, err := NewSensorFromPin(DHT22, newMockPin(test.wave, 1),
+				quickOptions(WithCaptureLimits(0, limit))...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			start := mockNow()
+			_, err = sensor.Read()
+			elapsed := mockNow() - start
+			if !errors.Is(err, test.want) && (test.want != nil || err != nil) {
+				t.Errorf("got %v, want %v", err, test.want)
+			}
+			if elapsed < test.min || elapsed > test.max {
+				t.Errorf("capture took %v, want %v..%v", elapsed, test.min,
+					test.max)
+			}
+		})
+	}
+}
+
+// Capture of slow board longer than initial buffer grows the buffer,
+// and sensor keeps grown buffer for next capture.
+func TestCaptureBufferGrows(t *testing.T) {
+	const edges = maxPulseCount * 5 / 4
+	wave := []level{{1, 30 * time.Microsecond}}
+	for value := 0; len(wave) <= edges; value ^= 1 {
+		wave = append(wave, level{value, 20 * time.Microsecond})
+	}
+	pin := newMockPin(wave, 1)
+	pin.readTime = 4 * mockReadTime
+	sensor, err := NewSensorFromPin(DHT22, pin,
+		quickOptions(WithCaptureLimits(0, time.Second))...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = sensor.Read()
+	if err == nil || errors.Is(err, ErrCaptureTruncated) {
+		t.Fatalf("got %v, want bad frame captured in full", err)
+	}
+	if len(sensor.values) <= edges*2 {
+		t.Fatalf("buffer has %d values, want more than %d", len(sensor.values),
+			edges*2)
+	}
+	buf := &sensor.values[0]
+	pulses, err := sensor.Capture()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pulses) < edges {
+		t.Errorf("got %d pulses, want at least %d", len(pulses), edges)
+	}
+	if &sensor.values[0] != buf {
+		t.Error("buffer allocated again for next capture")
+	}
+}
+
+func TestReadStalledCapture(t *testing.T) {
+	// Thread is descheduled longer than response timeout right after
+	// line is released, so the first sample after release see sensor
+	// already responding.
+	pin := newMockPin(responseWave(dht22Bytes(652, -35)), 1)
+	pin.stall = 3 * responseTimeout
+	sensor, err := NewSensorFromPin(DHT22, pin, quickOptions()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reading, err := sensor.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reading.Humidity != 65.2 || reading.Temperature != -3.5 {
+		t.Errorf("got %v°C %v%%, want -3.5°C 65.2%%",
+			reading.Temperature, reading.Humidity)
+	}
+}
+
+func TestStartSignalFailureReleasesLine(t *testing.T) {
+	injected := errors.New("injected")
+	tests := []struct {
+		method string
+		// Calls of SetDirection: failed one and release.
+		setDirection int
+	}{
+		{"Write", 2},
+		{"SetDirection", 2},
+	}
+	for _, test := range tests {
+		pin := newMockPin(nil, 1)
+		pin.fail[test.method] = injected
+		var arr []int64
+		err := dialDHTxxAndRead(context.Background(), pin, 0,
+			startSignal{low: time.Millisecond}, captureLimits{}, nil, &arr)
+		var gpioErr *GPIOError
+		if !errors.Is(err, injected) || !errors.As(err, &gpioErr) {
+			t.Errorf("%s failure: got %v, want GPIOError", test.method, err)
+		}
+		if Kind(err) != KindGPIO {
+			t.Errorf("%s failure: kind %v, want %v", test.method, Kind(err),
+				KindGPIO)
+		}
+		if pin.dir != embd.In {
+			t.Errorf("%s failure: line left driven", test.method)
+		}
+		if n := pin.count("SetDirection"); n != test.setDirection {
+			t.Errorf("%s failure: SetDirection called %d times, want %d",
+				test.method, n, test.setDirection)
+		}
+		if pin.count("Close") != 0 {
+			t.Errorf("%s failure: pin owned by caller was closed", test.method)
+		}
+	}
+}
+
+func TestBlinkNTimesCleanup(t *testing.T) {
+	injected := errors.New("injected")
+	closeErr := errors.New("close failed")
+	tests := []struct {
+		name     string
+		fail     string
+		closeErr error
+	}{
+		{"success", "", nil},
+		{"direction failure", "SetDirection", nil},
+		{"write failure", "Write", nil},
+		{"write and close failure", "Write", closeErr},
+		{"close failure", "", closeErr},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pin := newMockPin(nil, 1)
+			if test.fail != "" {
+				pin.fail[test.fail] = injected
+			}
+			pin.fail["Close"] = test.closeErr
+			fake := installFakeGPIO(t, pin)
+			err := blinkNTimes(pin.N(), 0)
+			if (test.fail != "") != errors.Is(err, injected) {
+				t.Errorf("got %v, want injected failure: %v", err,
+					test.fail != "")
+			}
+			if (test.closeErr != nil) != errors.Is(err, closeErr) {
+				t.Errorf("got %v, want close failure: %v", err,
+					test.closeErr != nil)
+			}
+			if n := pin.count("Close"); n != 1 {
+				t.Errorf("pin closed %d times, want 1", n)
+			}
+			if fake.inits != 1 || fake.closes != 1 {
+				t.Errorf("GPIO initialized %d times, closed %d times, want 1",
+					fake.inits, fake.closes)
+			}
+		})
+	}
+}
+
+func TestRetryOnlyTransientErrors(t *testing.T) {
+	injected := errors.New("injected")
+	const retry = 3
+	tests := []struct {
+		name string
+		// Set up fake GPIO and pins.
+		setup   func(t *testing.T) *fakeGPIO
+		want    error
+		retried int
+	}{
+		{"GPIO init failure", func(t *testing.T) *fakeGPIO {
+			fake := installFakeGPIO(t)
+			fake.initErr = injected
+			return fake
+		}, injected, 0},
+		{"pin open failure", func(t *testing.T) *fakeGPIO {
+			fake := installFakeGPIO(t)
+			fake.openErr = injected
+			return fake
+		}, injected, 0},
+		{"pin write failure", func(t *testing.T) *fakeGPIO {
+			// Pin is reopened once after GPIO failure
+			pins := []*mockPin{newMockPin(nil, 1), newMockPin(nil, 1)}
+			for _, pin := range pins {
+				pin.fail["Write"] = injected
+			}
+			return installFakeGPIO(t, pins...)
+		}, injected, 0},
+		{"no sensor", func(t *testing.T) *fakeGPIO {
+			return installFakeGPIO(t, newMockPin(nil, 0))
+		}, ErrNoSensor, 0},
+		{"no response", func(t *testing.T) *fakeGPIO {
+			return installFakeGPIO(t, newMockPin(nil, 1))
+		}, ErrNoResponse, retry},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			clock := installFakeClock(t)
+			test.setup(t)
+			temperature, humidity, retried, err := ReadDHTxxWithRetry(DHT22,
+				4, false, retry)
+			if !errors.Is(err, test.want) {
+				t.Fatalf("got %v, want %v", err, test.want)
+			}
+			if temperature != 0 || humidity != 0 {
+				t.Errorf("got %v°C %v%% on error", temperature, humidity)
+			}
+			if retried != test.retried || len(clock.pauses) != test.retried {
+				t.Errorf("retried %d times, paused %d times, want %d",
+					retried, len(clock.pauses), test.retried)
+			}
+			var retryErr *RetryError
+			if errors.As(err, &retryErr) != (test.retried > 0) {
+				t.Errorf("got %T, want RetryError: %v", err, test.retried > 0)
+			}
+		})
+	}
+}
+
+func TestReadDHTxxZeroOnError(t *testing.T) {
+	installFakeGPIO(t, newMockPin(nil, 1))
+	temperature, humidity, err := ReadDHTxx(DHT22, 4, false)
+	if !errors.Is(err, ErrNoResponse) {
+		t.Fatalf("got %v, want %v", err, ErrNoResponse)
+	}
+	if temperature != 0 || humidity != 0 {
+		t.Errorf("got %v°C %v%% on error, want zeros", temperature, humidity)
+	}
+}
+
+func TestDecodeMinusOne(t *testing.T) {
+	reading, err := DecodePulses(DHT22, responsePulses(dht22Bytes(423, -10)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reading.Temperature != -1 || reading.Humidity != 42.3 {
+		t.Errorf("got %v %v, want -1.0°C 42.3%%", reading.Temperature,
+			reading.Humidity)
+	}
+}
+
+func TestErrorsThroughReadDHTxxWithRetry(t *testing.T) {
+	badChecksum := dht22Bytes(452, 213)
+	badChecksum[4]++
+	truncated := responseWave(dht22Bytes(452, 213))[:3+40]
+	longBit := responseWave(dht22Bytes(452, 213))
+	longBit[3+2*5+1].dur = 150 * time.Microsecond
+	tests := []struct {
+		name  string
+		wave  []level
+		want  []error
+		check func(t *testing.T, err error)
+	}{
+		{"checksum", responseWave(badChecksum), []error{ErrChecksum},
+			func(t *testing.T, err error) {
+				var checksumErr *ChecksumError
+				if !errors.As(err, &checksumErr) ||
+					checksumErr.Expected != badChecksum[4]-1 ||
+					checksumErr.Bytes != badChecksum {
+					t.Errorf("got %#v", err)
+				}
+			}},
+		{"pulse count", truncated, []error{ErrBadPulseCount, ErrBadFrame},
+			func(t *testing.T, err error) {
+				var countErr *PulseCountError
+				if !errors.As(err, &countErr) || countErr.Count >= 80 {
+					t.Errorf("got %#v", err)
+				}
+			}},
+		{"pulse", longBit, []error{ErrBadPulse, ErrBadFrame},
+			func(t *testing.T, err error) {
+				var pulseErr *PulseError
+				if !errors.As(err, &pulseErr) ||
+					pulseErr.Pulse.Duration != 150*time.Microsecond {
+					t.Errorf("got %#v", err)
+				}
+			}},
+		{"humidity", responseWave(dht22Bytes(1200, 213)),
+			[]error{ErrHumidityOutOfRange}, func(t *testing.T, err error) {
+				var rangeErr *OutOfRangeError
+				if !errors.As(err, &rangeErr) || rangeErr.Value != 120 ||
+					rangeErr.Reading.Temperature != 21.3 {
+					t.Errorf("got %#v", err)
+				}
+			}},
+		{"no response", nil, []error{ErrNoResponse}, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			installFakeClock(t)
+			installFakeGPIO(t, newMockPin(test.wave, 1))
+			_, _, retried, err := ReadDHTxxWithRetry(DHT22, 4, false, 1)
+			for _, want := range test.want {
+				if !errors.Is(err, want) {
+					t.Errorf("got %v, want %v", err, want)
+				}
+			}
+			var retryErr *RetryError
+			if retried != 1 || !errors.As(err, &retryErr) {
+				t.Errorf("retried %d times, got %T", retried, err)
+			}
+			if test.check != nil {
+				test.check(t, err)
+			}
+		})
+	}
+}
+
+func TestErrTimeout(t *testing.T) {
+	pin := newMockPin(nil, 1)
+	sensor, err := NewSensorFromPin(DHT22, pin,
+		WithStartHold(time.Second), WithTimeout(time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sensor.Read(); !errors.Is(err, ErrTimeout) {
+		t.Errorf("got %v, want %v", err, ErrTimeout)
+	}
+}
+
+func TestUnknownSensorType(t *testing.T) {
+	pulses := responsePulses(dht22Bytes(452, 213))
+	for _, sensorType := range []SensorType{SensorType(0), SensorType(99)} {
+		check := func(what string, err error) {
+			t.Helper()
+			var typeErr *UnknownSensorTypeError
+			if !errors.Is(err, ErrUnknownSensorType) ||
+				!errors.As(err, &typeErr) || typeErr.Type != sensorType {
+				t.Errorf("%v: %s: got %v, want %v", int(sensorType), what,
+					err, ErrUnknownSensorType)
+			}
+		}
+		reading, err := DecodePulses(sensorType, pulses)
+		check("decode", err)
+		if reading.Temperature != 0 || reading.Humidity != 0 {
+			t.Errorf("%v: got %+v on error", int(sensorType), reading)
+		}
+		// GPIO isn't touched at all
+		fake := installFakeGPIO(t, newMockPin(nil, 1))
+		temperature, humidity, err := ReadDHTxx(sensorType, 4, false)
+		check("read", err)
+		if temperature != 0 || humidity != 0 {
+			t.Errorf("%v: got %v°C %v%% on error", int(sensorType),
+				temperature, humidity)
+		}
+		if fake.inits != 0 || fake.opened != 0 {
+			t.Errorf("%v: GPIO initialized %d times, %d pins opened",
+				int(sensorType), fake.inits, fake.opened)
+		}
+		_, err = sensorType.MarshalText()
+		check("marshal", err)
+	}
+}
+
+// Sensor names are parsed case-insensitively, alias included,
+// and marshaled back as canonical lower case name.
+func TestSensorTypeText(t *testing.T) {
+	tests := []struct {
+		text string
+		want SensorType
+		// Text marshaled back, same as text if empty.
+		canonical string
+		err       error
+	}{
+		{"dht11", DHT11, "", nil},
+		{"dht22", DHT22, "", nil},
+		{"DHT22", DHT22, "dht22", nil},
+		{" dht11 ", DHT11, "dht11", nil},
+		{"am2302", AM2302, "dht22", nil},
+		{"AM2302", DHT22, "dht22", nil},
+		{"dht33", 0, "", ErrUnknownSensorType},
+		{"", 0, "", ErrUnknownSensorType},
+	}
+	for _, test := range tests {
+		parsed, err := ParseSensorType(test.text)
+		var unmarshaled SensorType
+		unmarshalErr := unmarshaled.UnmarshalText([]byte(test.text))
+		if test.err != nil {
+			if !errors.Is(err, test.err) || !errors.Is(unmarshalErr, test.err) {
+				t.Errorf("%q: got %v and %v, want %v", test.text, err,
+					unmarshalErr, test.err)
+			}
+			if unmarshaled != 0 {
+				t.Errorf("%q: unmarshaled %v on error", test.text, unmarshaled)
+			}
+			continue
+		}
+		if err != nil || unmarshalErr != nil {
+			t.Errorf("%q: got %v and %v", test.text, err, unmarshalErr)
+			continue
+		}
+		if parsed != test.want || unmarshaled != test.want {
+			t.Errorf("%q: got %v and %v, want %v", test.text, parsed,
+				unmarshaled, test.want)
+		}
+		canonical := test.canonical
+		if canonical == "" {
+			canonical = test.text
+		}
+		text, err := parsed.MarshalText()
+		if err != nil || string(text) != canonical {
+			t.Errorf("%q: marshaled %q, %v, want %q", test.text, text, err,
+				canonical)
+		}
+	}
+	_, err := SensorType(0).MarshalText()
+	if err == nil || err.Error() != "Unknown sensor type 0" {
+		t.Errorf("got %v, want Unknown sensor type 0", err)
+	}
+}
+
+// Glitch pulses picked up from noisy line.
+var glitch = Pulses{{Value: 1, Duration: 3 * time.Microsecond},
+	{Value: 0, Duration: 4 * time.Microsecond},
+	{Value: 1, Duration: 2 * time.Microsecond}}
+
+func TestAlignFrame(t *testing.T) {
+	b := dht22Bytes(452, 213)
+	good := responsePulses(b)
+	join := func(parts ...Pulses) Pulses {
+		var pulses Pulses
+		for _, part := range parts {
+			pulses = append(pulses, part...)
+		}
+		return pulses
+	}
+	tests := []struct {
+		name   string
+		pulses Pulses
+		// Whether strict mode decode it too.
+		strict bool
+	}{
+		{"complete", good, true},
+		{"missed first edge", good[1:], true},
+		{"frame only", good[3:], true},
+		{"leading junk", join(glitch, good), false},
+		{"trailing junk", join(good[:len(good)-1], glitch, good[len(good)-1:]),
+			false},
+		{"junk around", join(glitch, glitch, good, glitch), false},
+	}
+	for _, test := range tests {
+		got, err := decodeBytes(test.pulses, false, getLogger())
+		if err != nil || got != b {
+			t.Errorf("%s: got %v, %v, want %v", test.name, got, err, b)
+		}
+		got, err = decodeBytes(test.pulses, true, getLogger())
+		if test.strict && (err != nil || got != b) {
+			t.Errorf("%s, strict: got %v, %v, want %v", test.name, got, err, b)
+		}
+		if !test.strict && !errors.Is(err, ErrBadPulseCount) {
+			t.Errorf("%s, strict: got %v, want %v", test.name, err,
+				ErrBadPulseCount)
+		}
+	}
+}
+
+func TestPreamble(t *testing.T) {
+	b := dht22Bytes(452, 213)
+	us := time.Microsecond
+	// Capture of 84 pulses, starting with preamble of given durations.
+	capture := func(low, high time.Duration) Pulses {
+		pulses := append(Pulses(nil), responsePulses(b)[1:]...)
+		pulses[0].Duration, pulses[1].Duration = low, high
+		return pulses
+	}
+	tests := []struct {
+		name   string
+		pulses Pulses
+		ok     bool
+	}{
+		{"good", capture(80*us, 80*us), true},
+		{"tolerance", capture(60*us, 100*us), true},
+		{"short low", capture(30*us, 80*us), false},
+		{"short high", capture(80*us, 40*us), false},
+		{"long", capture(80*us, 150*us), false},
+		// Data bit in place of preamble
+		{"missing", capture(50*us, 20*us), false},
+	}
+	for _, test := range tests {
+		for _, strict := range []bool{false, true} {
+			got, err := decodeBytes(test.pulses, strict, getLogger())
+			if test.ok {
+				if err != nil || got != b {
+					t.Errorf("%s, strict %v: got %v, %v, want %v", test.name,
+						strict, got, err, b)
+				}
+				continue
+			}
+			var preambleErr *PreambleError
+			if !errors.As(err, &preambleErr) ||
+				!errors.Is(err, ErrBadPreamble) || !errors.Is(err, ErrBadFrame) {
+				t.Errorf("%s, strict %v: got %v, want %v", test.name, strict,
+					err, ErrBadPreamble)
+				continue
+			}
+			if preambleErr.Low != test.pulses[0] ||
+				preambleErr.High != test.pulses[1] {
+				t.Errorf("%s, strict %v: observed %v %v, want %v %v",
+					test.name, strict, preambleErr.Low, preambleErr.High,
+					test.pulses[0], test.pulses[1])
+			}
+		}
+	}
+}
+
+func TestNoResponseOrBadFrame(t *testing.T) {
+	good := responsePulses(dht22Bytes(452, 213))
+	// Sensor responded, but bits got lost
+	garbled := append(append(Pulses(nil), good[:30]...), good[len(good)-1])
+	tests := []struct {
+		name   string
+		pulses Pulses
+		want   error
+		other  error
+	}{
+		{"empty", nil, ErrNoResponse, ErrBadFrame},
+		{"line stays high", Pulses{{Value: 1, Duration: 10 * time.Millisecond}},
+			ErrNoResponse, ErrBadFrame},
+		{"garbled", garbled, ErrBadFrame, ErrNoResponse},
+		{"glitches only", append(glitch, good[len(good)-1]), ErrBadFrame,
+			ErrNoResponse},
+	}
+	for _, test := range tests {
+		_, err := DecodePulses(DHT22, test.pulses)
+		if !errors.Is(err, test.want) || errors.Is(err, test.other) {
+			t.Errorf("%s: got %v, want %v", test.name, err, test.want)
+		}
+	}
+}
@@ -0,0 +1,422 @@
+package dht
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Capture stopped by sample or duration limit
+// while line level was still changing.
+var ErrCaptureTruncated = errors.New("Capture truncated")
+
+// Error returned when capture exceed its limits (see WithCaptureLimits).
+// Keep counters to tell noisy line apart from wiring problems.
+// Satisfy errors.Is(err, ErrCaptureTruncated).
+type CaptureTruncatedError struct {
+	// Number of pin reads made.
+	Samples int
+	// Number of level changes detected.
+	Edges int
+	// Amount of pulses buffer could keep.
+	BufferSize int
+	// Time capture lasted.
+	Elapsed time.Duration
+}
+
+func (this *CaptureTruncatedError) Error() string {
+	return fmt.Sprintf("%v: line didn't become idle in %v "+
+		"(%d edges in %d samples)", ErrCaptureTruncated, this.Elapsed,
+		this.Edges, this.Samples)
+}
+
+func (this *CaptureTruncatedError) Unwrap() error {
+	return ErrCaptureTruncated
+}
+
+var (
+	// Control sum received from sensor doesn't match data bytes.
+	ErrChecksum = errors.New("Checksum mismatch")
+	// Captured pulse count doesn't fit DHTxx frame.
+	ErrBadPulseCount = errors.New("Bad pulse count")
+	// Pulse level or duration doesn't fit DHTxx bit encoding.
+	ErrBadPulse = errors.New("Bad pulse")
+	// Read didn't complete in time.
+	ErrTimeout = errors.New("Timeout")
+	// Humidity decoded from sensor response exceed 100%.
+	ErrHumidityOutOfRange = errors.New("Humidity out of range")
+	// Sensor didn't respond to start signal: line stays high.
+	ErrNoResponse = errors.New("No response from sensor")
+	// Sensor responded, but captured pulses don't make up valid frame.
+	// Matched by PulseCountError, PulseError and PreambleError along
+	// with their own sentinels.
+	ErrBadFrame = errors.New("Bad frame")
+	// Data line stays low after start signal: sensor is unwired
+	// or line is shorted to ground.
+	ErrNoSensor = errors.New("No sensor: data line stuck low")
+)
+
+// Error returned when control sum doesn't match.
+// Satisfy errors.Is(err, ErrChecksum).
+type ChecksumError struct {
+	// Five bytes received from sensor, last one is control sum.
+	Bytes [5]byte
+	// Control sum calculated from first four bytes.
+	Expected byte
+	// Copy of pulses captured from sensor.
+	Pulses Pulses
+}
+
+func (this *ChecksumError) Error() string {
+	b := this.Bytes
+	return fmt.Sprintf("Control sum %d doesn't match %d (%d+%d+%d+%d)",
+		b[4], this.Expected, b[0], b[1], b[2], b[3])
+}
+
+func (this *ChecksumError) Unwrap() error {
+	return ErrChecksum
+}
+
+// Error returned when pulse array has length which can't be decoded.
+// Satisfy errors.Is(err, ErrBadPulseCount) and errors.Is(err, ErrBadFrame).
+type PulseCountError struct {
+	Count int
+}
+
+func (this *PulseCountError) Error() string {
+	return fmt.Sprintf("Can't decode pulse array received from "+
+		"DHTxx sensor, since incorrect length: %d", this.Count)
+}
+
+func (this *PulseCountError) Unwrap() error {
+	return ErrBadPulseCount
+}
+
+func (this *PulseCountError) Is(target error) bool {
+	return target == ErrBadFrame
+}
+
+// Error returned when pulse at Index has unexpected level,
+// or its duration exceed MaxDuration.
+// Satisfy errors.Is(err, ErrBadPulse) and errors.Is(err, ErrBadFrame).
+type PulseError struct {
+	Index int
+	Pulse Pulse
+	// Set, if pulse is too long.
+	MaxDuration time.Duration
+}
+
+func (this *PulseError) Error() string {
+	if this.MaxDuration > 0 {
+		return fmt.Sprintf("High edge value duration %v exceed "+
+			"expected maximum amount %v", this.Pulse.Duration, this.MaxDuration)
+	} else if this.Pulse.Value != 0 {
+		return fmt.Sprintf("Low edge value expected at index %d", this.Index)
+	} else {
+		return fmt.Sprintf("High edge value expected at index %d", this.Index)
+	}
+}
+
+func (this *PulseError) Unwrap() error {
+	return ErrBadPulse
+}
+
+func (this *PulseError) Is(target error) bool {
+	return target == ErrBadFrame
+}
+
+// Sensor response doesn't start with 80us low and 80us high pulses.
+var ErrBadPreamble = errors.New("Bad response preamble")
+
+// Error returned when pulses at preamble position don't look like
+// response preamble. Keep observed pulses.
+// Satisfy errors.Is(err, ErrBadPreamble) and errors.Is(err, ErrBadFrame).
+type PreambleError struct {
+	Low  Pulse
+	High Pulse
+	// Bounds of preamble pulse duration.
+	MinDuration time.Duration
+	MaxDuration time.Duration
+}
+
+func (this *PreambleError) Error() string {
+	return fmt.Sprintf("%v: low %v (level %d), high %v (level %d), "+
+		"expected %v..%v each", ErrBadPreamble, this.Low.Duration,
+		this.Low.Value, this.High.Duration, this.High.Value,
+		this.MinDuration, this.MaxDuration)
+}
+
+func (this *PreambleError) Unwrap() error {
+	return ErrBadPreamble
+}
+
+func (this *PreambleError) Is(target error) bool {
+	return target == ErrBadFrame
+}
+
+// Error returned when decoded value is out of allowed range.
+// Satisfy errors.Is(err, ErrHumidityOutOfRange) for humidity.
+type OutOfRangeError struct {
+	// Decoded values, so caller may keep valid field
+	// or clamp offending one.
+	Reading Reading
+	// Either "humidity" or "temperature".
+	Field string
+	Value float32
+	Limit float32
+}
+
+func (this *OutOfRangeError) Error() string {
+	if this.Field == "humidity" && this.Value > this.Limit {
+		return fmt.Sprintf("Humidity value exceed %v%%: %v",
+			this.Limit, this.Value)
+	}
+	return fmt.Sprintf("Value of %s %v out of range, limit %v",
+		this.Field, this.Value, this.Limit)
+}
+
+func (this *OutOfRangeError) Unwrap() error {
+	if this.Field == "humidity" {
+		return ErrHumidityOutOfRange
+	}
+	return nil
+}
+
+// Value decoded from valid frame is out of sensor measurement range.
+var ErrOutOfSpec = errors.New("Value out of sensor specification")
+
+// Error returned by Reading.Validate.
+// Satisfy errors.Is(err, ErrOutOfSpec).
+type OutOfSpecError struct {
+	// Decoded values, including offending one.
+	Reading Reading
+	Sensor  SensorType
+	// Either "humidity" or "temperature".
+	Field string
+	Value float32
+	// Measurement range according to datasheet.
+	Min float32
+	Max float32
+}
+
+func (this *OutOfSpecError) Error() string {
+	return fmt.Sprintf("%v: %s %v of %v not in range %v..%v",
+		ErrOutOfSpec, this.Field, this.Value, this.Sensor, this.Min, this.Max)
+}
+
+func (this *OutOfSpecError) Unwrap() error {
+	return ErrOutOfSpec
+}
+
+// Boost was requested, but thread priority can't be raised,
+// usually because of missing root privileges.
+var ErrBoostUnavailable = errors.New("Boost unavailable")
+
+// Returned when sensor is read again before its minimum interval elapsed.
+var ErrTooSoon = errors.New("Sensor read too soon")
+
+// Error returned when previous successful read on the same pin
+// happened less than SensorType.MinInterval ago.
+// Satisfy errors.Is(err, ErrTooSoon).
+type TooSoonError struct {
+	// How long to wait before next read.
+	Remaining time.Duration
+}
+
+func (this *TooSoonError) Error() string {
+	return fmt.Sprintf("%v: wait %v before next read", ErrTooSoon,
+		this.Remaining)
+}
+
+func (this *TooSoonError) Unwrap() error {
+	return ErrTooSoon
+}
+
+// Value derived from reading field found invalid
+// (see Reading.TemperatureOK and Reading.HumidityOK).
+var ErrInvalidField = errors.New("Invalid reading field")
+
+// Calibration can't be solved from reference points collected.
+var ErrCalibration = errors.New("Can't solve calibration")
+
+// Returned when option passed to Sensor or read function is invalid.
+var ErrInvalidOption = errors.New("Invalid option")
+
+// Sensor type is neither DHT11 nor DHT22.
+var ErrUnknownSensorType = errors.New("Unknown sensor type")
+
+// Error returned when sensor type value is not recognized,
+// for instance zero value left unset.
+// Satisfy errors.Is(err, ErrUnknownSensorType).
+type UnknownSensorTypeError struct {
+	Type SensorType
+}
+
+func (this *UnknownSensorTypeError) Error() string {
+	return fmt.Sprintf("%v %d", ErrUnknownSensorType, int(this.Type))
+}
+
+func (this *UnknownSensorTypeError) Unwrap() error {
+	return ErrUnknownSensorType
+}
+
+// Error returned when GPIO operation fails.
+// Wrap original error received from embd.
+type GPIOError struct {
+	// Failed operation, for instance "set pin 4 direction".
+	Op  string
+	Err error
+}
+
+func newGPIOError(err error, format string, args ...interface{}) error {
+	return &GPIOError{Op: fmt.Sprintf(format, args...), Err: err}
+}
+
+func (this *GPIOError) Error() string {
+	return fmt.Sprintf("Can't %s: %v", this.Op, this.Err)
+}
+
+func (this *GPIOError) Unwrap() error {
+	return this.Err
+}
+
+// Error returned when all read attempts failed.
+// Unwrap to the error of the last attempt.
+type RetryError struct {
+	// Outcome of every attempt made.
+	Attempts []AttemptResult
+}
+
+func (this *RetryError) Error() string {
+	return fmt.Sprintf("%v (%d attempts made)", this.Unwrap(),
+		len(this.Attempts))
+}
+
+func (this *RetryError) Unwrap() error {
+	return this.Attempts[len(this.Attempts)-1].Err
+}
+
+// Cause of read failure suitable to bucket errors
+// without parsing messages.
+type ErrorKind int
+
+const (
+	// Error is nil or not produced by this package.
+	KindUnknown ErrorKind = iota
+	// Control sum mismatch.
+	KindChecksum
+	// Read didn't complete in time.
+	KindTimeout
+	// Captured pulse count doesn't fit DHTxx frame.
+	KindTooFewPulses
+	// Access to GPIO or priority boost denied,
+	// usually root privileges required.
+	KindPermission
+	// Any other GPIO failure.
+	KindGPIO
+	// Decoded value out of range or sensor specification.
+	KindOutOfRange
+	// Sensor didn't respond to start signal.
+	KindNoResponse
+	// Pulse level or duration doesn't fit DHTxx bit encoding
+	// or response preamble.
+	KindBadPulse
+	// Capture exceeded its sample or duration limit.
+	KindTruncated
+	// Read was cancelled via context.
+	KindCancelled
+	// Invalid option, unknown sensor type or use of closed Sensor.
+	KindUsage
+	// Sensor read before its minimum interval elapsed.
+	KindTooSoon
+	// Data line stuck low, sensor is missing.
+	KindNoSensor
+)
+
+// Implement Stringer interface.
+func (this ErrorKind) String() string {
+	switch this {
+	case KindChecksum:
+		return "checksum"
+	case KindTimeout:
+		return "timeout"
+	case KindTooFewPulses:
+		return "too-few-pulses"
+	case KindPermission:
+		return "permission"
+	case KindGPIO:
+		return "gpio"
+	case KindOutOfRange:
+		return "out-of-range"
+	case KindNoResponse:
+		return "no-response"
+	case KindBadPulse:
+		return "bad-pulse"
+	case KindTruncated:
+		return "truncated"
+	case KindCancelled:
+		return "cancelled"
+	case KindUsage:
+		return "usage"
+	case KindTooSoon:
+		return "too-soon"
+	case KindNoSensor:
+		return "no-sensor"
+	default:
+		return "unknown"
+	}
+}
+
+// Classify error returned by this package.
+func Kind(err error) ErrorKind {
+	var rangeErr *OutOfRangeError
+	var gpioErr *GPIOError
+	switch {
+	case err == nil:
+		return KindUnknown
+	case errors.Is(err, ErrChecksum):
+		return KindChecksum
+	case errors.Is(err, ErrTimeout), errors.Is(err, context.DeadlineExceeded):
+		return KindTimeout
+	case errors.Is(err, context.Canceled):
+		return KindCancelled
+	case errors.Is(err, ErrBadPulseCount):
+		return KindTooFewPulses
+	case errors.Is(err, ErrNoSensor):
+		return KindNoSensor
+	case errors.Is(err, ErrNoResponse):
+		return KindNoResponse
+	case errors.Is(err, ErrBadPulse), errors.Is(err, ErrBadPreamble):
+		return KindBadPulse
+	case errors.Is(err, ErrCaptureTruncated):
+		return KindTruncated
+	case errors.As(err, &rangeErr), errors.Is(err, ErrOutOfSpec):
+		return KindOutOfRange
+	case errors.Is(err, os.ErrPermission), errors.Is(err, ErrBoostUnavailable):
+		return KindPermission
+	case errors.As(err, &gpioErr):
+		return KindGPIO
+	case errors.Is(err, ErrInvalidOption), errors.Is(err, ErrSensorClosed),
+		errors.Is(err, ErrUnknownSensorType):
+		return KindUsage
+	case errors.Is(err, ErrTooSoon):
+		return KindTooSoon
+	default:
+		return KindUnknown
+	}
+}
+
+// Return true for errors caused by distorted or missing sensor response,
+// which usually disappear on next attempt. GPIO, permission, usage
+// errors and missing sensor are permanent, so retry doesn't make sense.
+func IsTransient(err error) bool {
+	switch Kind(err) {
+	case KindChecksum, KindTimeout, KindTooFewPulses, KindOutOfRange,
+		KindNoResponse, KindBadPulse, KindTruncated:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,103 @@
+package dht
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+// Read sensor playing back wave with mock pin.
+func readMock(wave []level, idle int, opts ...Option) error {
+	sensor, err := NewSensorFromPin(DHT22, newMockPin(wave, idle),
+		quickOptions(opts...)...)
+	if err != nil {
+		return err
+	}
+	defer sensor.Close()
+	_, err = sensor.Read()
+	return err
+}
+
+func TestKind(t *testing.T) {
+	good := responsePulses(dht22Bytes(452, 213))
+	badChecksum := dht22Bytes(452, 213)
+	badChecksum[4]++
+	longBit := append(Pulses(nil), good...)
+	longBit[3+2*5+1].Duration = 150 * time.Microsecond
+	badPreamble := append(Pulses(nil), good[1:]...)
+	badPreamble[0].Duration = 30 * time.Microsecond
+	var noisy []level
+	for i := 0; i < 1000; i++ {
+		noisy = append(noisy, level{i % 2, 10 * time.Microsecond})
+	}
+	decode := func(sensorType SensorType, pulses Pulses) error {
+		_, err := DecodePulses(sensorType, pulses)
+		return err
+	}
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	closed, err := NewSensorFromPin(DHT22, newMockPin(nil, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed.Close()
+	_, closedErr := closed.Read()
+	_, invalidErr := NewSensorFromPin(DHT22, nil)
+	tests := []struct {
+		name      string
+		err       error
+		kind      ErrorKind
+		transient bool
+	}{
+		{"nil", nil, KindUnknown, false},
+		{"foreign", errors.New("foreign"), KindUnknown, false},
+		{"checksum", decode(DHT22, responsePulses(badChecksum)),
+			KindChecksum, true},
+		{"retried checksum", &RetryError{Attempts: []AttemptResult{
+			{Err: ErrNoResponse}, {Err: &ChecksumError{}}}},
+			KindChecksum, true},
+		{"pulse count", decode(DHT22, good[:40]), KindTooFewPulses, true},
+		{"pulse", decode(DHT22, longBit), KindBadPulse, true},
+		{"preamble", decode(DHT22, badPreamble), KindBadPulse, true},
+		{"humidity", decode(DHT22, responsePulses(dht22Bytes(1200, 213))),
+			KindOutOfRange, true},
+		{"out of spec", Reading{Sensor: DHT22, Temperature: 90}.Validate(),
+			KindOutOfRange, true},
+		{"empty capture", decode(DHT22, nil), KindNoResponse, true},
+		{"unknown sensor type", decode(SensorType(0), good), KindUsage, false},
+		{"no response", readMock(nil, 1), KindNoResponse, true},
+		{"no sensor", readMock(nil, 0), KindNoSensor, false},
+		{"truncated", readMock(noisy, 1, WithCaptureLimits(500, time.Second)),
+			KindTruncated, true},
+		{"timeout", readMock(nil, 1, WithStartHold(time.Second),
+			WithTimeout(time.Millisecond)), KindTimeout, true},
+		{"deadline", context.DeadlineExceeded, KindTimeout, true},
+		{"cancelled", contextError(cancelled), KindCancelled, false},
+		{"permission", newGPIOError(os.ErrPermission, "initialize GPIO"),
+			KindPermission, false},
+		{"boost", fmt.Errorf("%w: not root", ErrBoostUnavailable),
+			KindPermission, false},
+		{"gpio", newGPIOError(errors.New("gone"), "open pin 4"),
+			KindGPIO, false},
+		{"invalid option", invalidErr, KindUsage, false},
+		{"closed", closedErr, KindUsage, false},
+		{"too soon", &TooSoonError{Remaining: time.Second}, KindTooSoon, false},
+	}
+	for _, test := range tests {
+		if (test.err == nil) != (test.name == "nil") {
+			t.Errorf("%s: unexpected error %v", test.name, test.err)
+			continue
+		}
+		if kind := Kind(test.err); kind != test.kind {
+			t.Errorf("%s: %v classified as %v, want %v", test.name, test.err,
+				kind, test.kind)
+		}
+		if IsTransient(test.err) != test.transient {
+			t.Errorf("%s: transient %v, want %v", test.name,
+				IsTransient(test.err), test.transient)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+//go:build go1.21
+
+package dht_test
+
+import (
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+
+	"github.com/stanier/go-dht"
+)
+
+func ExampleNewSlogLogger() {
+	// Debug records as text, time is left out to keep output stable
+	handler := slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey && len(groups) == 0 {
+				return slog.Attr{}
+			}
+			return a
+		}})
+	logger := dht.NewSlogLogger(slog.New(handler))
+	// Decode pulses captured earlier, as live read would
+	data, err := os.ReadFile("testdata/dht22.txt")
+	if err != nil {
+		log.Fatal(err)
+	}
+	pulses, err := dht.ParsePulses(string(data))
+	if err != nil {
+		log.Fatal(err)
+	}
+	trace := dht.Trace{Sensor: dht.DHT22, Pin: 4, Pulses: pulses}
+	reading, err := trace.Decode(dht.WithLogger(logger))
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(reading.Temperature, reading.Humidity)
+	// Output:
+	// level=DEBUG msg="Five bytes from DHTxx" sensor=dht22 bytes="[1 196 0 213 154]"
+	// 21.3°C 45.2%
+}
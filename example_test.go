@@ -0,0 +1,29 @@
+package dht_test
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/stanier/go-dht"
+)
+
+func ExampleReadAuto() {
+	// Read sensor connected to pin 4, whatever type it is
+	reading, err := dht.ReadAuto(4)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Temperature = %v, Humidity = %v\n",
+		reading.Temperature, reading.Humidity)
+}
+
+func ExampleSensorType_Set() {
+	// Let user pick sensor type in command line, DHT22 by default
+	flags := flag.NewFlagSet("dht", flag.ExitOnError)
+	sensorType := dht.DHT22
+	flags.Var(&sensorType, "type", "sensor type: dht11|dht22|am2302")
+	flags.Parse([]string{"-type", "DHT11"})
+	fmt.Println(sensorType)
+	// Output: DHT11
+}
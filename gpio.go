@@ -0,0 +1,77 @@
+package dht
+
+import (
+	"sync"
+
+	"github.com/kidoman/embd"
+)
+
+// GPIO functions of embd, replaced by tests with fakes.
+var (
+	initGPIO      = embd.InitGPIO
+	closeGPIO     = embd.CloseGPIO
+	newDigitalPin = embd.NewDigitalPin
+)
+
+// Pin connected through inverting level shifter:
+// levels written and read are inverted.
+type invertedPin struct {
+	embd.DigitalPin
+}
+
+func (this invertedPin) Write(val int) error {
+	return this.DigitalPin.Write(invertLevel(val))
+}
+
+func (this invertedPin) Read() (int, error) {
+	val, err := this.DigitalPin.Read()
+	if err != nil {
+		return 0, err
+	}
+	return invertLevel(val), nil
+}
+
+func invertLevel(val int) int {
+	if val == embd.Low {
+		return embd.High
+	}
+	return embd.Low
+}
+
+// Number of users of GPIO initialized by this package. Since
+// embd.CloseGPIO close all pins opened through embd, GPIO is closed only
+// when last sensor release it.
+var gpioRefs = struct {
+	sync.Mutex
+	n int
+}{}
+
+// Initialize GPIO on first use, count further users.
+func acquireGPIO() error {
+	gpioRefs.Lock()
+	defer gpioRefs.Unlock()
+	if gpioRefs.n == 0 {
+		if err := initGPIO(); err != nil {
+			return newGPIOError(err, "initialize GPIO")
+		}
+	}
+	gpioRefs.n++
+	return nil
+}
+
+// Close GPIO, once every user acquired it released it.
+func releaseGPIO() error {
+	gpioRefs.Lock()
+	defer gpioRefs.Unlock()
+	if gpioRefs.n == 0 {
+		return nil
+	}
+	gpioRefs.n--
+	if gpioRefs.n > 0 {
+		return nil
+	}
+	if err := closeGPIO(); err != nil {
+		return newGPIOError(err, "close GPIO")
+	}
+	return nil
+}
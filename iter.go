@@ -0,0 +1,50 @@
+//go:build go1.23
+
+package dht
+
+import (
+	"context"
+	"iter"
+	"time"
+)
+
+// Read sensor every interval (never more often than sensor's minimum
+// interval) until ctx is done or consumer breaks the loop:
+//
+//	for reading, err := range sensor.Readings(ctx, 10*time.Second) {
+//		...
+//	}
+//
+// Failed reads are yielded with their error. If consumer is slower
+// than interval, missed ticks are skipped rather than queued.
+// Minimum interval is waited out even without WithMinIntervalWait.
+func (this *Sensor) Readings(ctx context.Context,
+	interval time.Duration) iter.Seq2[Reading, error] {
+	if interval < this.cfg.minInterval {
+		interval = this.cfg.minInterval
+	}
+	return func(yield func(Reading, error) bool) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			reading, _, err := this.readWithRetry(ctx)
+			if ctx.Err() != nil {
+				return
+			}
+			if !yield(reading, err) {
+				return
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			// Ticker runs since read start, while minimum interval is
+			// counted since reading is decoded, so wait out the rest of it
+			wait := remainingInterval(this.pin, this.cfg.minInterval)
+			if wait > 0 && pause(ctx, wait) != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,40 @@
+//go:build go1.23
+
+package dht
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestReadingsRespectMinInterval(t *testing.T) {
+	b := [5]byte{45, 0, 21, 0, 66}
+	pin := newMockPin(responseWave(b), 1)
+	// No WithMinIntervalWait: too early read would fail with ErrTooSoon
+	sensor, err := NewSensorFromPin(DHT11, pin,
+		quickOptions(WithRetries(3))...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var readings []Reading
+	for reading, err := range sensor.Readings(ctx, 0) {
+		if err != nil {
+			t.Fatalf("read %d: %v", len(readings), err)
+		}
+		readings = append(readings, reading)
+		if len(readings) == 3 {
+			break
+		}
+	}
+	if len(readings) != 3 {
+		t.Fatalf("got %d readings, want 3", len(readings))
+	}
+	for i := 1; i < len(readings); i++ {
+		if d := readings[i].Time.Sub(readings[i-1].Time); d < time.Second {
+			t.Errorf("readings %d and %d are %v apart", i-1, i, d)
+		}
+	}
+}
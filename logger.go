@@ -1,19 +1,140 @@
 package dht
 
 import (
+	"fmt"
+	stdlog "log"
 	"os"
+	"sync"
 
 	"github.com/op/go-logging"
 )
 
+// Destination of diagnostic output. Satisfied by *logging.Logger
+// from github.com/op/go-logging, use NewStdLogger to adapt
+// standard library logger.
+type Logger interface {
+	Debugf(format string, args ...interface{})
+	Warningf(format string, args ...interface{})
+}
+
 // Comment INFO and uncomment DEBUG if you want detail debug output in library.
 var log *logging.Logger = buildLogger("dht",
 	//	logging.DEBUG,
 	logging.INFO,
 )
 
+// Logger accepting message with key-value pairs, such as slog adapter
+// returned by NewSlogLogger. Diagnostics are sent to it as fields
+// (pin, sensor, pulse_count, error_kind, ...) instead of formatted strings.
+type FieldLogger interface {
+	Logger
+	DebugFields(msg string, keyvals ...interface{})
+	WarningFields(msg string, keyvals ...interface{})
+}
+
+// Return false, if l surely discard debug output, so expensive debug
+// messages are not built in vain. Logger may tell this with
+// DebugEnabled() bool method.
+func debugEnabled(l Logger) bool {
+	switch l := l.(type) {
+	case interface{ DebugEnabled() bool }:
+		return l.DebugEnabled()
+	case *logging.Logger:
+		return l.IsEnabledFor(logging.DEBUG)
+	default:
+		return true
+	}
+}
+
+// Key-value pairs rendered as "key=value" only when printed.
+type fields []interface{}
+
+func (this fields) String() string {
+	s := ""
+	for i := 0; i+1 < len(this); i += 2 {
+		s += fmt.Sprintf(" %v=%v", this[i], this[i+1])
+	}
+	return s
+}
+
+// Send debug message with fields to l, either as fields
+// or as formatted string, depending on what l supports.
+func logDebug(l Logger, msg string, keyvals ...interface{}) {
+	if fl, ok := l.(FieldLogger); ok {
+		fl.DebugFields(msg, keyvals...)
+		return
+	}
+	l.Debugf("%s:%v", msg, fields(keyvals))
+}
+
+// Same as logDebug, but for warnings.
+func logWarning(l Logger, msg string, keyvals ...interface{}) {
+	if fl, ok := l.(FieldLogger); ok {
+		fl.WarningFields(msg, keyvals...)
+		return
+	}
+	l.Warningf("%s:%v", msg, fields(keyvals))
+}
+
 var terminalBackend logging.LeveledBackend = nil
 
+// Logger used by package functions and by default in Sensor.
+// Initially it's log, which discard debug output.
+var packageLogger = struct {
+	sync.RWMutex
+	l Logger
+}{l: log}
+
+// Replace package logger. Pass nil to discard all output.
+// Sensors created earlier keep logger they got on creation.
+func SetLogger(l Logger) {
+	if l == nil {
+		l = discardLogger{}
+	}
+	packageLogger.Lock()
+	defer packageLogger.Unlock()
+	packageLogger.l = l
+}
+
+// Return current package logger.
+func getLogger() Logger {
+	packageLogger.RLock()
+	defer packageLogger.RUnlock()
+	return packageLogger.l
+}
+
+type discardLogger struct{}
+
+func (discardLogger) Debugf(format string, args ...interface{})   {}
+func (discardLogger) Warningf(format string, args ...interface{}) {}
+func (discardLogger) DebugEnabled() bool                          { return false }
+
+// Adapter of standard library logger to Logger interface.
+type stdLogger struct {
+	l     *stdlog.Logger
+	debug bool
+}
+
+// Wrap standard library logger, debug output is written
+// only if debug is true.
+func NewStdLogger(l *stdlog.Logger, debug bool) Logger {
+	return &stdLogger{l: l, debug: debug}
+}
+
+func (this *stdLogger) Debugf(format string, args ...interface{}) {
+	if this.debug {
+		this.l.Printf("DEBUG "+format, args...)
+	}
+}
+
+func (this *stdLogger) DebugEnabled() bool {
+	return this.debug
+}
+
+func (this *stdLogger) Warningf(format string, args ...interface{}) {
+	this.l.Printf("WARNING "+format, args...)
+}
+
 func buildLogger(module string, level logging.Level) *logging.Logger {
 	// Set the backends to be used.
 	if terminalBackend == nil {
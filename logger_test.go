@@ -0,0 +1,108 @@
+package dht
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+// Logger keeping messages in memory.
+type recordLogger struct {
+	messages []string
+}
+
+func (this *recordLogger) Debugf(format string, args ...interface{}) {
+	this.messages = append(this.messages, fmt.Sprintf(format, args...))
+}
+
+func (this *recordLogger) Warningf(format string, args ...interface{}) {
+	this.messages = append(this.messages, fmt.Sprintf(format, args...))
+}
+
+// Return true, if some message starts with prefix.
+func (this *recordLogger) has(prefix string) bool {
+	for _, msg := range this.messages {
+		if strings.HasPrefix(msg, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestSensorLogger(t *testing.T) {
+	good := responseWave(dht22Bytes(452, 213))
+	tests := []struct {
+		name string
+		wave []level
+		want string
+	}{
+		{"decoded bytes", good, "Five bytes from DHTxx"},
+		{"pulses which can't be aligned", good[:3+40], "Pulses captured"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pkg := &recordLogger{}
+			save := getLogger()
+			SetLogger(pkg)
+			defer SetLogger(save)
+			own := &recordLogger{}
+			sensor, err := NewSensorFromPin(DHT22, newMockPin(test.wave, 1),
+				quickOptions(WithLogger(own), WithPulseDump())...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sensor.Read()
+			if !own.has(test.want) {
+				t.Errorf("sensor logger got %q, want %q", own.messages,
+					test.want)
+			}
+			if len(pkg.messages) != 0 {
+				t.Errorf("package logger got %q", pkg.messages)
+			}
+		})
+	}
+}
+
+// Library must never write to stdout, which belongs to application.
+func TestNoStdoutOnFailedRead(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	save := os.Stdout
+	os.Stdout = w
+	defer func() {
+		os.Stdout = save
+	}()
+	output := make(chan []byte)
+	go func() {
+		data, _ := io.ReadAll(r)
+		output <- data
+	}()
+
+	badChecksum := dht22Bytes(452, 213)
+	badChecksum[4]++
+	waves := [][]level{nil, responseWave(badChecksum),
+		responseWave(dht22Bytes(452, 213))[:3+40]}
+	for _, wave := range waves {
+		// Default package logger with pulse dump on failure
+		sensor, err := NewSensorFromPin(DHT22, newMockPin(wave, 1),
+			quickOptions(WithPulseDump())...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := sensor.Read(); err == nil {
+			t.Error("read succeeded, want failure")
+		}
+		installFakeGPIO(t, newMockPin(wave, 1))
+		if _, _, err := ReadDHTxx(DHT22, 4, false); err == nil {
+			t.Error("ReadDHTxx succeeded, want failure")
+		}
+	}
+	w.Close()
+	if data := <-output; len(data) != 0 {
+		t.Errorf("written to stdout: %q", data)
+	}
+}
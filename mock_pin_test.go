@@ -0,0 +1,340 @@
+package dht
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kidoman/embd"
+)
+
+// Level held by data line for some time.
+type level struct {
+	value int
+	dur   time.Duration
+}
+
+// Virtual monotonic time, which every read of mock pin advance by
+// mockReadTime, so pulses are timed exactly however test is scheduled.
+var mockClock struct {
+	sync.Mutex
+	t time.Duration
+}
+
+const mockReadTime = time.Microsecond
+
+func mockNow() time.Duration {
+	mockClock.Lock()
+	defer mockClock.Unlock()
+	return mockClock.t
+}
+
+func advanceMockClock(d time.Duration) time.Duration {
+	mockClock.Lock()
+	defer mockClock.Unlock()
+	mockClock.t += d
+	return mockClock.t
+}
+
+func TestMain(m *testing.M) {
+	now = mockNow
+	os.Exit(m.Run())
+}
+
+// mockPin emulate data line with sensor attached. Once pin is switched
+// from output to input, sensor response is played back in virtual time,
+// afterwards line stays at idle level. Every call is recorded and
+// failure can be injected per method.
+type mockPin struct {
+	mu sync.Mutex
+	n  int
+	// Response played back once line is released.
+	wave []level
+	// Level line stays at, when there is no response or it's over.
+	idle int
+	// Pause before second read of each capture, emulating thread
+	// descheduled right after line was released. Sensor response
+	// is delayed, so playback continues where sensor pulls line low.
+	stall time.Duration
+	// Stall only that many captures (zero means every capture).
+	stallCount int
+	// Time every read takes, mockReadTime if zero. Emulate slow board.
+	readTime time.Duration
+	// Errors returned by methods, by method name.
+	fail map[string]error
+
+	calls    []string
+	dir      embd.Direction
+	out      int
+	released time.Duration
+	reads    int
+	stalled  int
+}
+
+var mockPinNumber int32
+
+// Create mock pin with unique number, so reads of different tests
+// don't hit shared minimum interval.
+func newMockPin(wave []level, idle int) *mockPin {
+	n := int(atomic.AddInt32(&mockPinNumber, 1)) + 1000
+	return &mockPin{n: n, wave: wave, idle: idle, dir: embd.In,
+		fail: make(map[string]error)}
+}
+
+// Record call and return injected failure, if any. Caller should hold the lock.
+func (this *mockPin) call(name string) error {
+	this.calls = append(this.calls, name)
+	return this.fail[name]
+}
+
+// Return how many times method was called.
+func (this *mockPin) count(name string) int {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	n := 0
+	for _, call := range this.calls {
+		if call == name {
+			n++
+		}
+	}
+	return n
+}
+
+func (this *mockPin) lastCall() string {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	if len(this.calls) == 0 {
+		return ""
+	}
+	return this.calls[len(this.calls)-1]
+}
+
+func (this *mockPin) Watch(edge embd.Edge, handler func(embd.DigitalPin)) error {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	return this.call("Watch")
+}
+
+func (this *mockPin) StopWatching() error {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	return this.call("StopWatching")
+}
+
+func (this *mockPin) N() int {
+	return this.n
+}
+
+func (this *mockPin) Write(val int) error {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	if err := this.call("Write"); err != nil {
+		return err
+	}
+	this.out = val
+	return nil
+}
+
+func (this *mockPin) Read() (int, error) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	// Reads are too frequent to record them all
+	if err := this.fail["Read"]; err != nil {
+		return 0, err
+	}
+	if this.dir == embd.Out {
+		return this.out, nil
+	}
+	readTime := this.readTime
+	if readTime == 0 {
+		readTime = mockReadTime
+	}
+	t := advanceMockClock(readTime)
+	if this.released == 0 {
+		return this.idle, nil
+	}
+	this.reads++
+	if this.reads == 2 && this.stall > 0 &&
+		(this.stallCount == 0 || this.stalled < this.stallCount) {
+		this.stalled++
+		t = advanceMockClock(this.stall)
+		if len(this.wave) > 0 {
+			this.released = t - this.wave[0].dur
+		}
+	}
+	elapsed := t - this.released
+	for _, l := range this.wave {
+		if elapsed < l.dur {
+			return l.value, nil
+		}
+		elapsed -= l.dur
+	}
+	return this.idle, nil
+}
+
+func (this *mockPin) TimePulse(state int) (time.Duration, error) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	return 0, this.call("TimePulse")
+}
+
+func (this *mockPin) SetDirection(dir embd.Direction) error {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	if err := this.call("SetDirection"); err != nil {
+		return err
+	}
+	// Sensor respond once line is released after start signal
+	if this.dir == embd.Out && dir == embd.In {
+		this.released = advanceMockClock(mockReadTime)
+		this.reads = 0
+	}
+	this.dir = dir
+	return nil
+}
+
+func (this *mockPin) ActiveLow(b bool) error {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	return this.call("ActiveLow")
+}
+
+func (this *mockPin) PullUp() error {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	return this.call("PullUp")
+}
+
+func (this *mockPin) PullDown() error {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	return this.call("PullDown")
+}
+
+func (this *mockPin) Close() error {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	return this.call("Close")
+}
+
+// Fake embd GPIO: pins are opened from the list in order,
+// every call is counted and failure can be injected.
+type fakeGPIO struct {
+	pins   []*mockPin
+	opened int
+	inits  int
+	closes int
+
+	initErr  error
+	closeErr error
+	openErr  error
+}
+
+// Replace embd GPIO functions with fake until test is over.
+func installFakeGPIO(t *testing.T, pins ...*mockPin) *fakeGPIO {
+	fake := &fakeGPIO{pins: pins}
+	saveInit, saveClose, saveNew := initGPIO, closeGPIO, newDigitalPin
+	t.Cleanup(func() {
+		initGPIO, closeGPIO, newDigitalPin = saveInit, saveClose, saveNew
+	})
+	initGPIO = func() error {
+		fake.inits++
+		return fake.initErr
+	}
+	closeGPIO = func() error {
+		fake.closes++
+		return fake.closeErr
+	}
+	newDigitalPin = func(key interface{}) (embd.DigitalPin, error) {
+		if fake.openErr != nil {
+			return nil, fake.openErr
+		}
+		if fake.opened >= len(fake.pins) {
+			return nil, errors.New("no more pins")
+		}
+		fake.opened++
+		return fake.pins[fake.opened-1], nil
+	}
+	return fake
+}
+
+// Fake clock recording pauses between reads instead of sleeping.
+type fakeClock struct {
+	pauses []time.Duration
+}
+
+// Replace pause with fake clock until test is over.
+func installFakeClock(t *testing.T) *fakeClock {
+	clock := &fakeClock{}
+	save := pause
+	t.Cleanup(func() {
+		pause = save
+	})
+	pause = func(ctx context.Context, d time.Duration) error {
+		if ctx.Err() != nil {
+			return contextError(ctx)
+		}
+		clock.pauses = append(clock.pauses, d)
+		return nil
+	}
+	return clock
+}
+
+// Build 5 bytes sent by DHT22 for humidity and temperature in tenths,
+// including checksum.
+func dht22Bytes(hum, temp int) [5]byte {
+	var b [5]byte
+	b[0], b[1] = byte(hum>>8), byte(hum)
+	if temp < 0 {
+		temp = -temp
+		b[2] = 0x80
+	}
+	b[2] |= byte(temp >> 8)
+	b[3] = byte(temp)
+	b[4] = b[0] + b[1] + b[2] + b[3]
+	return b
+}
+
+// Duration of high pulse encoding bit value.
+func bitDuration(bit byte) time.Duration {
+	if bit != 0 {
+		return 75 * time.Microsecond
+	}
+	return 20 * time.Microsecond
+}
+
+// Waveform of sensor response carrying bytes b: line released high,
+// preamble, 40 bits and final low pulse.
+func responseWave(b [5]byte) []level {
+	wave := []level{{1, 30 * time.Microsecond},
+		{0, 80 * time.Microsecond}, {1, 80 * time.Microsecond}}
+	for _, x := range b {
+		for i := 7; i >= 0; i-- {
+			wave = append(wave, level{0, 50 * time.Microsecond},
+				level{1, bitDuration(x >> i & 1)})
+		}
+	}
+	return append(wave, level{0, 50 * time.Microsecond})
+}
+
+// Pulses captured for response carrying bytes b, as they come
+// from live read: high line before response, preamble, 40 bits,
+// final low pulse and idle line.
+func responsePulses(b [5]byte) Pulses {
+	pulses := Pulses{{Value: 1, Duration: 30 * time.Microsecond},
+		{Value: 0, Duration: 80 * time.Microsecond},
+		{Value: 1, Duration: 80 * time.Microsecond}}
+	for _, x := range b {
+		for i := 7; i >= 0; i-- {
+			pulses = append(pulses,
+				Pulse{Value: 0, Duration: 50 * time.Microsecond},
+				Pulse{Value: 1, Duration: bitDuration(x >> i & 1)})
+		}
+	}
+	return append(pulses, Pulse{Value: 0, Duration: 50 * time.Microsecond},
+		Pulse{Value: 1, Duration: 10 * time.Millisecond})
+}
@@ -0,0 +1,400 @@
+package dht
+
+import (
+	"fmt"
+	"time"
+)
+
+// Option change Sensor and read functions behavior.
+type Option func(*config) error
+
+// Settings collected from options.
+type config struct {
+	boost bool
+	// Read without boost if it can't be applied.
+	boostFallback bool
+	retries       int
+	retryDelay    time.Duration
+	backoff       float64
+	maxDelay      time.Duration
+	timeout       time.Duration
+	// Minimum interval between reads of sensor type.
+	minInterval time.Duration
+	// Wait rather than fail, if previous read was too recent.
+	waitInterval bool
+	// Prime sensor with extra read after long idle.
+	doubleRead bool
+	// Check readings against sensor type datasheet ranges.
+	strictRange bool
+	// Humidity above 100% up to this value is clamped to 100%.
+	clampHumidity float32
+	// Period of unchanged humidity, after which it's considered
+	// stuck, zero if stuck humidity isn't detected.
+	stuckHumidity time.Duration
+	// Correction applied to decoded values, nil if none.
+	calibration *calibration
+	// Temperature excess caused by self-heating, zero if none.
+	selfHeating float32
+	// GPIO is initialized and closed by application.
+	externalGPIO bool
+	// Timing of start signal, depend on sensor type.
+	startSignal startSignal
+	// Encoding of negative DHT22 temperatures.
+	negativeEncoding NegativeEncoding
+	// Find frame by pulse count only, as earlier versions did.
+	strictFrame bool
+	// Data line goes through inverting level shifter.
+	invertedSignal bool
+	// State data line is left in between reads.
+	idlePolicy IdlePolicy
+	// Bounds of single capture.
+	captureLimits captureLimits
+	// Dump pulses of failed reads only, but at warning level.
+	pulseDump bool
+	logger    Logger
+}
+
+// Build settings from options, stop on first invalid option.
+// Defaults depend on sensor type.
+func newConfig(sensorType SensorType, opts []Option) (*config, error) {
+	// Reject unknown sensor type before GPIO is touched
+	spec, err := sensorType.spec()
+	if err != nil {
+		return nil, err
+	}
+	interval := spec.minInterval
+	cfg := &config{retryDelay: interval, backoff: 1,
+		timeout: 2 * time.Second, minInterval: interval,
+		startSignal: startSignal{hold: 50 * time.Millisecond,
+			low: spec.startLow},
+		captureLimits: captureLimits{maxDuration: 100 * time.Millisecond},
+		logger:        getLogger()}
+	for _, opt := range opts {
+		if err := opt(cfg); err != nil {
+			return nil, err
+		}
+	}
+	return cfg, nil
+}
+
+// Boost GPIO performance during read. Should be used for old devices
+// such as Raspberry PI 1 (this will require root privileges).
+func WithBoost() Option {
+	return func(cfg *config) error {
+		cfg.boost = true
+		return nil
+	}
+}
+
+// Read without boost, logging warning, if priority can't be raised,
+// instead of failing with ErrBoostUnavailable.
+func WithBoostFallback() Option {
+	return func(cfg *config) error {
+		cfg.boostFallback = true
+		return nil
+	}
+}
+
+// Retry read n times in case of failure.
+func WithRetries(n int) Option {
+	return func(cfg *config) error {
+		if n < 0 {
+			return fmt.Errorf("%w: retry count can't be negative: %d",
+				ErrInvalidOption, n)
+		}
+		cfg.retries = n
+		return nil
+	}
+}
+
+// Pause before first retry attempt. By default it's 1 second for DHT11
+// and 2 seconds for DHT22, according to datasheets.
+func WithRetryDelay(delay time.Duration) Option {
+	return func(cfg *config) error {
+		if delay <= 0 {
+			return fmt.Errorf("%w: retry delay should be positive: %v",
+				ErrInvalidOption, delay)
+		}
+		cfg.retryDelay = delay
+		return nil
+	}
+}
+
+// Multiply retry delay by factor after each failed retry,
+// but never pause longer than maxDelay (zero means no limit).
+func WithRetryBackoff(factor float64, maxDelay time.Duration) Option {
+	return func(cfg *config) error {
+		if factor < 1 {
+			return fmt.Errorf("%w: backoff factor can't be less than 1: %v",
+				ErrInvalidOption, factor)
+		}
+		if maxDelay < 0 {
+			return fmt.Errorf("%w: maximum retry delay can't be negative: %v",
+				ErrInvalidOption, maxDelay)
+		}
+		cfg.backoff = factor
+		cfg.maxDelay = maxDelay
+		return nil
+	}
+}
+
+// Return pause before retry attempt following the one
+// made after delay.
+func (this *config) nextRetryDelay(delay time.Duration) time.Duration {
+	next := time.Duration(float64(delay) * this.backoff)
+	if this.maxDelay > 0 && next > this.maxDelay {
+		next = this.maxDelay
+	}
+	return next
+}
+
+// Limit time single read attempt may take, including activation,
+// capture and decoding, 2 seconds by default. Attempt which doesn't
+// complete in time fails with ErrTimeout.
+func WithTimeout(timeout time.Duration) Option {
+	return func(cfg *config) error {
+		if timeout <= 0 {
+			return fmt.Errorf("%w: timeout should be positive: %v",
+				ErrInvalidOption, timeout)
+		}
+		cfg.timeout = timeout
+		return nil
+	}
+}
+
+// Override how long line is pulled low to wake sensor up, by default
+// 18 milliseconds for DHT11 and 2 milliseconds for DHT22 (AM2302).
+// Some clones need different timing.
+func WithStartSignal(low time.Duration) Option {
+	return func(cfg *config) error {
+		if low <= 0 {
+			return fmt.Errorf("%w: start signal duration should be positive: %v",
+				ErrInvalidOption, low)
+		}
+		cfg.startSignal.low = low
+		return nil
+	}
+}
+
+// Hold line high for d before start signal, 50 milliseconds by default.
+// Longer hold may help with long cables, zero disables hold. Hold is
+// skipped anyway, if line was kept high by IdleHigh or IdlePullUp policy
+// (where pull-up is supported) at least minimum interval since previous read.
+func WithStartHold(d time.Duration) Option {
+	return func(cfg *config) error {
+		if d < 0 {
+			return fmt.Errorf("%w: start hold can't be negative: %v",
+				ErrInvalidOption, d)
+		}
+		cfg.startSignal.hold = d
+		return nil
+	}
+}
+
+// Decode negative DHT22 temperatures according to encoding,
+// SignMagnitude by default. Use TwosComplement or AutoDetect for clones
+// which report, for instance, -3276.0°C instead of -0.8°C.
+func WithNegativeEncoding(encoding NegativeEncoding) Option {
+	return func(cfg *config) error {
+		switch encoding {
+		case SignMagnitude, TwosComplement, AutoDetect:
+			cfg.negativeEncoding = encoding
+			return nil
+		default:
+			return fmt.Errorf("%w: unknown negative temperature encoding: %d",
+				ErrInvalidOption, encoding)
+		}
+	}
+}
+
+// Find data frame in captured pulses by pulse count (82-85 pulses
+// are accepted), as earlier versions did, rather than by response
+// preamble.
+func WithStrictFrame() Option {
+	return func(cfg *config) error {
+		cfg.strictFrame = true
+		return nil
+	}
+}
+
+// Support data line connected through inverting level shifter, such as
+// single transistor one: levels are inverted both when start signal is
+// sent and when response is captured, as well as idle line level.
+// Use Pulses.Invert to decode pulses captured on such line elsewhere.
+func WithInvertedSignal() Option {
+	return func(cfg *config) error {
+		cfg.invertedSignal = true
+		return nil
+	}
+}
+
+// Leave data line in state set by policy after every read and on Close,
+// IdleInput by default. Boards without pull-up resistor on data line
+// need IdlePullUp or IdleHigh, otherwise line floats between reads.
+func WithIdlePolicy(policy IdlePolicy) Option {
+	return func(cfg *config) error {
+		switch policy {
+		case IdleInput, IdlePullUp, IdleHigh:
+			cfg.idlePolicy = policy
+			return nil
+		default:
+			return fmt.Errorf("%w: unknown idle policy: %d",
+				ErrInvalidOption, policy)
+		}
+	}
+}
+
+// Limit capture of sensor response: stop after maxSamples pin reads
+// (zero means no limit) or after maxDuration, 100 milliseconds by default.
+// Capture normally ends in a few milliseconds, once line stays idle,
+// so limits matter only for noisy line. Capture stopped by limit fails
+// with ErrCaptureTruncated. Slow boards may need bigger limits.
+func WithCaptureLimits(maxSamples int, maxDuration time.Duration) Option {
+	return func(cfg *config) error {
+		if maxSamples < 0 {
+			return fmt.Errorf("%w: capture sample limit can't be negative: %d",
+				ErrInvalidOption, maxSamples)
+		}
+		if maxDuration <= 0 {
+			return fmt.Errorf("%w: capture duration limit should be positive: %v",
+				ErrInvalidOption, maxDuration)
+		}
+		cfg.captureLimits = captureLimits{maxSamples: maxSamples,
+			maxDuration: maxDuration}
+		return nil
+	}
+}
+
+// Wait until minimum interval between reads (see SensorType.MinInterval)
+// elapse, instead of failing with ErrTooSoon.
+func WithMinIntervalWait() Option {
+	return func(cfg *config) error {
+		cfg.waitInterval = true
+		return nil
+	}
+}
+
+// Read sensor twice after long idle, returning the second reading only:
+// sensor report values measured on previous start signal, so the first
+// read after idle is stale. Reads are separated by minimum interval.
+// Extra read is skipped, if pin was successfully read within twice
+// minimum interval. Extra read is made once and its failure is ignored,
+// retries apply to returned read only. Discarded reading is kept
+// in Reading.Discarded.
+func WithDoubleRead() Option {
+	return func(cfg *config) error {
+		cfg.doubleRead = true
+		return nil
+	}
+}
+
+// Fail with OutOfSpecError when temperature or humidity fall out of
+// range sensor type is able to measure (see Reading.Validate).
+// Without this option only humidity above 100% is rejected.
+func WithStrictRange() Option {
+	return func(cfg *config) error {
+		cfg.strictRange = true
+		return nil
+	}
+}
+
+// Clamp humidity above 100% down to 100%, as long as it doesn't exceed
+// limit, instead of failing with ErrHumidityOutOfRange. AM2302 in
+// saturated air often report 100.1-100.9% with valid control sum.
+// Clamped readings have Reading.Clamped set.
+func WithClampHumidity(limit float32) Option {
+	return func(cfg *config) error {
+		if limit < 100 {
+			return fmt.Errorf("%w: humidity clamp limit can't be below 100%%: %v",
+				ErrInvalidOption, limit)
+		}
+		cfg.clampHumidity = limit
+		return nil
+	}
+}
+
+// Consider humidity stuck, once it stays exactly the same for period
+// while temperature changes: sensing element is likely dead. Such
+// readings have Reading.HumidityOK false, temperature is still valid.
+func WithStuckHumidity(period time.Duration) Option {
+	return func(cfg *config) error {
+		if period <= 0 {
+			return fmt.Errorf("%w: stuck humidity period should be positive: %v",
+				ErrInvalidOption, period)
+		}
+		cfg.stuckHumidity = period
+		return nil
+	}
+}
+
+// Linear correction of values decoded from sensor.
+type calibration struct {
+	tempOffset float32
+	tempScale  float32
+	humOffset  float32
+	humScale   float32
+}
+
+// Correct decoded values: temperature*tempScale + tempOffset and
+// humidity*humScale + humOffset. Calibration is applied before
+// humidity clamping and range validation, raw values are kept
+// in Reading.RawTemperature and Reading.RawHumidity.
+func WithCalibration(tempOffset, tempScale, humOffset,
+	humScale float32) Option {
+	return func(cfg *config) error {
+		if tempScale <= 0 || humScale <= 0 {
+			return fmt.Errorf("%w: calibration scale should be positive: %v, %v",
+				ErrInvalidOption, tempScale, humScale)
+		}
+		cfg.calibration = &calibration{tempOffset: tempOffset,
+			tempScale: tempScale, humOffset: humOffset, humScale: humScale}
+		return nil
+	}
+}
+
+// Compensate sensor heated by itself or by board nearby, for instance
+// in small enclosure: subtract deltaT from temperature and correct
+// relative humidity to the same absolute humidity at lowered temperature.
+// Compensation follows calibration, readings are flagged Compensated.
+func WithSelfHeatingCompensation(deltaT float32) Option {
+	return func(cfg *config) error {
+		if deltaT <= 0 {
+			return fmt.Errorf("%w: self-heating delta should be positive: %v",
+				ErrInvalidOption, deltaT)
+		}
+		cfg.selfHeating = deltaT
+		return nil
+	}
+}
+
+// Don't initialize and close GPIO: application does it itself,
+// probably because it use other pins through embd, which would be
+// closed along with GPIO. Sensor opens and closes its pin only.
+func WithExternalGPIO() Option {
+	return func(cfg *config) error {
+		cfg.externalGPIO = true
+		return nil
+	}
+}
+
+// Dump captured pulses as warning when they can't be decoded, instead
+// of dumping pulses of every read at debug level. Useful to diagnose
+// sensor without turning on debug output.
+func WithPulseDump() Option {
+	return func(cfg *config) error {
+		cfg.pulseDump = true
+		return nil
+	}
+}
+
+// Send diagnostic output to logger l instead of package logger
+// (see SetLogger).
+func WithLogger(l Logger) Option {
+	return func(cfg *config) error {
+		if l == nil {
+			return fmt.Errorf("%w: logger can't be nil", ErrInvalidOption)
+		}
+		cfg.logger = l
+		return nil
+	}
+}
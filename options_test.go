@@ -0,0 +1,207 @@
+package dht
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/kidoman/embd"
+)
+
+func TestClampHumidity(t *testing.T) {
+	tests := []struct {
+		hum     int
+		opts    []Option
+		want    float32
+		clamped bool
+		err     error
+	}{
+		{1000, nil, 100, false, nil},
+		{1005, nil, 0, false, ErrHumidityOutOfRange},
+		{1000, []Option{WithClampHumidity(102)}, 100, false, nil},
+		{1005, []Option{WithClampHumidity(102)}, 100, true, nil},
+		{1020, []Option{WithClampHumidity(102)}, 100, true, nil},
+		{1200, []Option{WithClampHumidity(102)}, 0, false,
+			ErrHumidityOutOfRange},
+	}
+	for _, test := range tests {
+		cfg, err := newConfig(DHT22, test.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pulses := responsePulses(dht22Bytes(test.hum, 213))
+		reading, err := decodePulses(DHT22, pulses, cfg)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%v%% with %d options: got %v, want %v",
+				float32(test.hum)/10, len(test.opts), err, test.err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if float32(reading.Humidity) != test.want ||
+			reading.Clamped != test.clamped ||
+			float32(reading.RawHumidity) != float32(test.hum)/10 {
+			t.Errorf("%v%% with %d options: got %v (raw %v, clamped %v), "+
+				"want %v (clamped %v)", float32(test.hum)/10, len(test.opts),
+				reading.Humidity, reading.RawHumidity, reading.Clamped,
+				test.want, test.clamped)
+		}
+	}
+}
+
+func TestClampHumidityInvalidLimit(t *testing.T) {
+	_, err := newConfig(DHT22, []Option{WithClampHumidity(99.9)})
+	if !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("got %v, want %v", err, ErrInvalidOption)
+	}
+}
+
+func TestSelfHeatingCompensation(t *testing.T) {
+	tests := []struct {
+		hum, temp int
+		deltaT    float32
+	}{
+		{452, 213, 1.5},
+		{300, 50, 2},
+		{800, -105, 0.7},
+		{10, 350, 1},
+	}
+	for _, test := range tests {
+		cfg, err := newConfig(DHT22,
+			[]Option{WithSelfHeatingCompensation(test.deltaT)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		pulses := responsePulses(dht22Bytes(test.hum, test.temp))
+		reading, err := decodePulses(DHT22, pulses, cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rawTemp, rawHum := float32(test.temp)/10, float32(test.hum)/10
+		if float32(reading.RawTemperature) != rawTemp ||
+			float32(reading.RawHumidity) != rawHum || !reading.Compensated {
+			t.Errorf("%v°C %v%%: got raw %v %v, compensated %v", rawTemp,
+				rawHum, reading.RawTemperature, reading.RawHumidity,
+				reading.Compensated)
+		}
+		if !near(float32(reading.Temperature), rawTemp-test.deltaT) {
+			t.Errorf("%v°C %v%%: got %v, want %v°C", rawTemp, rawHum,
+				reading.Temperature, rawTemp-test.deltaT)
+		}
+		// Cooler air of the same absolute humidity is more humid
+		if reading.Humidity <= reading.RawHumidity {
+			t.Errorf("%v°C %v%%: humidity %v not raised", rawTemp, rawHum,
+				reading.Humidity)
+		}
+		want := AbsoluteHumidity(rawTemp, rawHum)
+		got := AbsoluteHumidity(float32(reading.Temperature),
+			float32(reading.Humidity))
+		if math.Abs(float64(got-want)) > 1e-4*float64(want) {
+			t.Errorf("%v°C %v%%: absolute humidity %v, want %v", rawTemp,
+				rawHum, got, want)
+		}
+	}
+}
+
+func TestSelfHeatingCompensationErrors(t *testing.T) {
+	// Humidity raised above 100% fails as any other
+	cfg, err := newConfig(DHT22, []Option{WithSelfHeatingCompensation(2)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pulses := responsePulses(dht22Bytes(950, 213))
+	_, err = decodePulses(DHT22, pulses, cfg)
+	if !errors.Is(err, ErrHumidityOutOfRange) {
+		t.Errorf("got %v, want %v", err, ErrHumidityOutOfRange)
+	}
+	for _, deltaT := range []float32{0, -1} {
+		_, err := newConfig(DHT22,
+			[]Option{WithSelfHeatingCompensation(deltaT)})
+		if !errors.Is(err, ErrInvalidOption) {
+			t.Errorf("delta %v: got %v, want %v", deltaT, err,
+				ErrInvalidOption)
+		}
+	}
+}
+
+// Invert levels of wave, as seen behind inverting level shifter.
+func invertWave(wave []level) []level {
+	inverted := make([]level, len(wave))
+	for i, l := range wave {
+		inverted[i] = level{1 - l.value, l.dur}
+	}
+	return inverted
+}
+
+func TestInvertedSignal(t *testing.T) {
+	wave := responseWave(dht22Bytes(452, 213))
+	pin := newMockPin(invertWave(wave), 0)
+	sensor, err := NewSensorFromPin(DHT22, pin,
+		quickOptions(WithInvertedSignal(), WithIdlePolicy(IdleHigh))...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reading, err := sensor.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reading.Temperature != 21.3 || reading.Humidity != 45.2 {
+		t.Errorf("got %v %v, want 21.3°C 45.2%%", reading.Temperature,
+			reading.Humidity)
+	}
+	// Idle line is held high behind level shifter
+	if pin.dir != embd.Out || pin.out != embd.Low {
+		t.Errorf("line left in direction %v at level %d", pin.dir, pin.out)
+	}
+
+	// Same capture without option
+	if err := readMock(invertWave(wave), 0); err == nil {
+		t.Errorf("inverted line decoded without option")
+	}
+
+	// Line stuck at physical level, which is inverted logical one
+	tests := []struct {
+		idle int
+		want error
+	}{
+		{1, ErrNoSensor},
+		{0, ErrNoResponse},
+	}
+	for _, test := range tests {
+		err := readMock(nil, test.idle, WithInvertedSignal())
+		if !errors.Is(err, test.want) {
+			t.Errorf("line stuck at %d: got %v, want %v", test.idle, err,
+				test.want)
+		}
+	}
+}
+
+func TestStuckHumidity(t *testing.T) {
+	pin := newMockPin(responseWave(dht22Bytes(12, 213)), 1)
+	sensor, err := NewSensorFromPin(DHT22, pin,
+		quickOptions(WithStuckHumidity(time.Nanosecond),
+			WithMinIntervalWait())...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	installFakeClock(t)
+	for i, temp := range []int{213, 215, 215} {
+		pin.wave = responseWave(dht22Bytes(12, temp))
+		reading, err := sensor.Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Humidity is stuck, once temperature moved
+		if stuck := i > 0; reading.HumidityOK() == stuck ||
+			!reading.TemperatureOK() {
+			t.Errorf("read %d: humidity valid %v, temperature valid %v",
+				i, reading.HumidityOK(), reading.TemperatureOK())
+		}
+	}
+	_, err = NewSensorFromPin(DHT22, pin, WithStuckHumidity(0))
+	if !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("got %v, want %v", err, ErrInvalidOption)
+	}
+}
@@ -0,0 +1,144 @@
+package dht
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"syscall"
+	"unsafe"
+)
+
+// Real-time FIFO scheduling policy and its maximum priority,
+// same as original C code used (sched_get_priority_max).
+const (
+	schedFIFO        = 1
+	schedMaxPriority = 99
+)
+
+type schedParam struct {
+	priority int32
+}
+
+// Thread scheduling syscalls, replaced by tests with fakes.
+var (
+	getScheduler  = schedGetScheduler
+	getSchedParam = schedGetParam
+	setScheduler  = schedSetScheduler
+	getPriority   = syscall.Getpriority
+	setPriority   = syscall.Setpriority
+)
+
+// Scheduling settings of calling thread to restore after boost.
+type prioritySave struct {
+	tid int
+	// Scheduling policy and its parameters, if policy was changed.
+	fifo   bool
+	policy int
+	param  schedParam
+	// Nice value, if it was changed.
+	niced bool
+	nice  int
+}
+
+// Lock calling goroutine to its thread and switch thread to real-time
+// FIFO scheduling, or at least raise its nice value to -20 if real-time
+// scheduling is denied. Both require root privileges.
+// Call restorePriority with returned settings once done, preferably
+// with defer, so priority is restored on panic as well.
+func raisePriority() (prioritySave, error) {
+	runtime.LockOSThread()
+	save := prioritySave{tid: syscall.Gettid()}
+	fifoErr := setFIFO(&save)
+	if fifoErr == nil {
+		return save, nil
+	}
+	niceErr := setNice(&save)
+	if niceErr == nil {
+		return save, nil
+	}
+	runtime.UnlockOSThread()
+	return prioritySave{}, fmt.Errorf("%w: %w", ErrBoostUnavailable,
+		errors.Join(fifoErr, niceErr))
+}
+
+// Switch thread to FIFO policy, saving previous one.
+func setFIFO(save *prioritySave) error {
+	policy, err := getScheduler(save.tid)
+	if err != nil {
+		return err
+	}
+	param, err := getSchedParam(save.tid)
+	if err != nil {
+		return err
+	}
+	err = setScheduler(save.tid, schedFIFO,
+		schedParam{priority: schedMaxPriority})
+	if err != nil {
+		return err
+	}
+	save.fifo = true
+	save.policy = policy
+	save.param = param
+	return nil
+}
+
+// Raise thread nice value to -20, saving previous one.
+func setNice(save *prioritySave) error {
+	// Kernel return priority as 20 - nice
+	prio, err := getPriority(syscall.PRIO_PROCESS, save.tid)
+	if err != nil {
+		return err
+	}
+	if err := setPriority(syscall.PRIO_PROCESS, save.tid, -20); err != nil {
+		return err
+	}
+	save.niced = true
+	save.nice = 20 - prio
+	return nil
+}
+
+func schedGetScheduler(tid int) (int, error) {
+	policy, _, errno := syscall.RawSyscall(syscall.SYS_SCHED_GETSCHEDULER,
+		uintptr(tid), 0, 0)
+	if errno != 0 {
+		return 0, errno
+	}
+	return int(policy), nil
+}
+
+func schedGetParam(tid int) (schedParam, error) {
+	var param schedParam
+	_, _, errno := syscall.RawSyscall(syscall.SYS_SCHED_GETPARAM,
+		uintptr(tid), uintptr(unsafe.Pointer(&param)), 0)
+	if errno != 0 {
+		return schedParam{}, errno
+	}
+	return param, nil
+}
+
+func schedSetScheduler(tid int, policy int, param schedParam) error {
+	_, _, errno := syscall.RawSyscall(syscall.SYS_SCHED_SETSCHEDULER,
+		uintptr(tid), uintptr(policy), uintptr(unsafe.Pointer(&param)))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// Return thread scheduling to settings saved by raisePriority
+// and unlock goroutine from thread.
+func restorePriority(save prioritySave) error {
+	defer runtime.UnlockOSThread()
+	if save.fifo {
+		if err := setScheduler(save.tid, save.policy, save.param); err != nil {
+			return fmt.Errorf("Can't restore thread scheduling policy: %w", err)
+		}
+	}
+	if save.niced {
+		err := setPriority(syscall.PRIO_PROCESS, save.tid, save.nice)
+		if err != nil {
+			return fmt.Errorf("Can't restore thread priority: %w", err)
+		}
+	}
+	return nil
+}
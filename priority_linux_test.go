@@ -0,0 +1,141 @@
+package dht
+
+import (
+	"errors"
+	"testing"
+)
+
+// Fake thread scheduling: keep policy and nice value of the only
+// thread, failure can be injected when policy is raised or restored.
+type fakeScheduler struct {
+	policy int
+	param  schedParam
+	nice   int
+
+	raiseErr   error
+	restoreErr error
+	// Number of times previous policy was restored.
+	restores int
+}
+
+// Replace scheduling syscalls with fake until test is over.
+func installFakeScheduler(t *testing.T) *fakeScheduler {
+	fake := &fakeScheduler{}
+	saveGet, saveParam, saveSet := getScheduler, getSchedParam, setScheduler
+	saveGetPrio, saveSetPrio := getPriority, setPriority
+	t.Cleanup(func() {
+		getScheduler, getSchedParam, setScheduler = saveGet, saveParam, saveSet
+		getPriority, setPriority = saveGetPrio, saveSetPrio
+	})
+	getScheduler = func(tid int) (int, error) {
+		return fake.policy, nil
+	}
+	getSchedParam = func(tid int) (schedParam, error) {
+		return fake.param, nil
+	}
+	setScheduler = func(tid int, policy int, param schedParam) error {
+		if policy == schedFIFO {
+			if fake.raiseErr != nil {
+				return fake.raiseErr
+			}
+		} else {
+			fake.restores++
+			if fake.restoreErr != nil {
+				return fake.restoreErr
+			}
+		}
+		fake.policy, fake.param = policy, param
+		return nil
+	}
+	getPriority = func(which, who int) (int, error) {
+		return 20 - fake.nice, nil
+	}
+	setPriority = func(which, who, prio int) error {
+		fake.nice = prio
+		return nil
+	}
+	return fake
+}
+
+func TestBoostRestoreFailure(t *testing.T) {
+	injected := errors.New("injected")
+	fake := installFakeScheduler(t)
+	fake.restoreErr = injected
+	pin := newMockPin(responseWave(dht22Bytes(452, 213)), 1)
+	sensor, err := NewSensorFromPin(DHT22, pin, quickOptions(WithBoost())...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sensor.Read(); !errors.Is(err, injected) {
+		t.Errorf("got %v, want restore failure", err)
+	}
+	if fake.restores != 1 {
+		t.Errorf("policy restored %d times, want 1", fake.restores)
+	}
+}
+
+// Pin calling hook before every read of line level.
+type hookPin struct {
+	*mockPin
+	hook func()
+}
+
+func (this hookPin) Read() (int, error) {
+	this.hook()
+	return this.mockPin.Read()
+}
+
+// Priority raised for capture is restored however capture ends.
+func TestBoostRestoredOnFailure(t *testing.T) {
+	injected := errors.New("injected")
+	tests := []struct {
+		name string
+		// True, if FIFO policy is unavailable, so nice value is raised.
+		nice  bool
+		panic bool
+	}{
+		{"FIFO, error", false, false},
+		{"FIFO, panic", false, true},
+		{"nice, error", true, false},
+		{"nice, panic", true, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fake := installFakeScheduler(t)
+			if test.nice {
+				fake.raiseErr = injected
+			}
+			boosted := false
+			pin := hookPin{newMockPin(responseWave(dht22Bytes(452, 213)), 1),
+				func() {
+					boosted = fake.policy == schedFIFO || fake.nice == -20
+					if test.panic {
+						panic(injected)
+					}
+				}}
+			pin.fail["Read"] = injected
+			sensor, err := NewSensorFromPin(DHT22, pin,
+				quickOptions(WithBoost())...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			func() {
+				defer func() {
+					if r := recover(); (r != nil) != test.panic {
+						t.Errorf("recovered %v, want panic: %v", r, test.panic)
+					}
+				}()
+				if _, err := sensor.Read(); !errors.Is(err, injected) {
+					t.Errorf("got %v, want read failure", err)
+				}
+			}()
+			if !boosted {
+				t.Error("priority wasn't raised during capture")
+			}
+			if fake.policy != 0 || fake.nice != 0 {
+				t.Errorf("policy %d, nice %d left, want 0, 0", fake.policy,
+					fake.nice)
+			}
+		})
+	}
+}
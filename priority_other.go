@@ -0,0 +1,20 @@
+//go:build !linux
+
+package dht
+
+import (
+	"fmt"
+	"runtime"
+)
+
+type prioritySave struct{}
+
+// Priority boost is implemented for Linux only.
+func raisePriority() (prioritySave, error) {
+	return prioritySave{}, fmt.Errorf("%w: not supported on %s",
+		ErrBoostUnavailable, runtime.GOOS)
+}
+
+func restorePriority(save prioritySave) error {
+	return nil
+}
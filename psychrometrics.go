@@ -0,0 +1,204 @@
+package dht
+
+import (
+	"math"
+)
+
+// Standard sea level atmospheric pressure in Pascal.
+const StandardPressure = 101325
+
+// Bunch of values derived from temperature and relative humidity.
+type Psychro struct {
+	// Dew point in Celsius, absent when air is completely dry.
+	DewPoint *float32 `json:"dew_point,omitempty"`
+	// Frost point in Celsius, present only when temperature below zero
+	// and air isn't completely dry.
+	FrostPoint *float32 `json:"frost_point,omitempty"`
+	// Absolute humidity in g/m³.
+	AbsoluteHumidity float32 `json:"absolute_humidity"`
+	// Mixing ratio in g of water vapor per kg of dry air.
+	MixingRatio float32 `json:"mixing_ratio"`
+	// Specific enthalpy in kJ per kg of dry air.
+	Enthalpy float32 `json:"enthalpy"`
+	// Vapor pressure deficit in kPa.
+	VPD float32 `json:"vpd"`
+	// Heat index (apparent temperature) in Celsius.
+	HeatIndex float32 `json:"heat_index"`
+}
+
+// Magnus formula coefficients over water and over ice.
+const (
+	magnusE0    = 611.2
+	magnusA     = 17.62
+	magnusB     = 243.12
+	magnusIceA  = 22.46
+	magnusIceB  = 272.62
+	gasConstH2O = 461.5
+)
+
+// Calculate psychrometric values from temperature in Celsius,
+// relative humidity in percent and atmospheric pressure in Pascal
+// (pass 0 to use StandardPressure). Saturation vapor pressure is
+// evaluated once and all values are derived from it, so they match
+// ones returned by DewPoint, AbsoluteHumidity and other helpers.
+func Psychrometrics(temperature, humidity float32, pressurePa float32) Psychro {
+	t, rh := float64(temperature), float64(humidity)
+	es := saturationPressure(t)
+	e := vaporPressure(es, rh)
+	var psy Psychro
+	// Dew point doesn't exist for completely dry air
+	if e > 0 {
+		dp := float32(dewPoint(e))
+		psy.DewPoint = &dp
+		if t < 0 {
+			fp := float32(frostPoint(e))
+			psy.FrostPoint = &fp
+		}
+	}
+	psy.AbsoluteHumidity = float32(absoluteHumidity(t, e))
+	w := mixingRatio(e, pressurePa)
+	psy.MixingRatio = float32(w * 1000)
+	psy.Enthalpy = float32(enthalpy(t, w))
+	psy.VPD = float32((es - e) / 1000)
+	psy.HeatIndex = float32(heatIndex(t, rh))
+	return psy
+}
+
+// Calculate psychrometric values for reading, see Psychrometrics.
+// Fail with ErrInvalidField, if either field is invalid.
+func (this Reading) Psychrometrics(pressurePa float32) (Psychro, error) {
+	if err := this.checkFields(); err != nil {
+		return Psychro{}, err
+	}
+	return Psychrometrics(float32(this.Temperature), float32(this.Humidity),
+		pressurePa), nil
+}
+
+// Return dew point in Celsius for temperature in Celsius and relative
+// humidity in percent, or NaN when humidity isn't positive.
+func DewPoint(temperature, humidity float32) float32 {
+	t := float64(temperature)
+	e := vaporPressure(saturationPressure(t), float64(humidity))
+	if e <= 0 {
+		return float32(math.NaN())
+	}
+	return float32(dewPoint(e))
+}
+
+// Return frost point in Celsius, or NaN when humidity isn't positive.
+// Makes sense for temperatures below zero.
+func FrostPoint(temperature, humidity float32) float32 {
+	t := float64(temperature)
+	e := vaporPressure(saturationPressure(t), float64(humidity))
+	if e <= 0 {
+		return float32(math.NaN())
+	}
+	return float32(frostPoint(e))
+}
+
+// Return absolute humidity in g/m³.
+func AbsoluteHumidity(temperature, humidity float32) float32 {
+	t := float64(temperature)
+	e := vaporPressure(saturationPressure(t), float64(humidity))
+	return float32(absoluteHumidity(t, e))
+}
+
+// Return mixing ratio in g of water vapor per kg of dry air,
+// pressure is in Pascal (0 means StandardPressure).
+func MixingRatio(temperature, humidity float32, pressurePa float32) float32 {
+	t := float64(temperature)
+	e := vaporPressure(saturationPressure(t), float64(humidity))
+	return float32(mixingRatio(e, pressurePa) * 1000)
+}
+
+// Return specific enthalpy in kJ per kg of dry air,
+// pressure is in Pascal (0 means StandardPressure).
+func Enthalpy(temperature, humidity float32, pressurePa float32) float32 {
+	t := float64(temperature)
+	e := vaporPressure(saturationPressure(t), float64(humidity))
+	return float32(enthalpy(t, mixingRatio(e, pressurePa)))
+}
+
+// Return vapor pressure deficit in kPa.
+func VPD(temperature, humidity float32) float32 {
+	t := float64(temperature)
+	es := saturationPressure(t)
+	return float32((es - vaporPressure(es, float64(humidity))) / 1000)
+}
+
+// Return heat index (apparent temperature) in Celsius.
+func HeatIndex(temperature, humidity float32) float32 {
+	return float32(heatIndex(float64(temperature), float64(humidity)))
+}
+
+// Return temperature lowered by deltaT and relative humidity,
+// which keeps absolute humidity unchanged at lowered temperature.
+func compensateSelfHeating(temperature, humidity,
+	deltaT float32) (float32, float32) {
+	t := float64(temperature)
+	tc := t - float64(deltaT)
+	rh := float64(humidity) * saturationPressure(t) / saturationPressure(tc) *
+		(tc + 273.15) / (t + 273.15)
+	return float32(tc), float32(rh)
+}
+
+// Saturation vapor pressure in Pascal by Magnus formula.
+func saturationPressure(t float64) float64 {
+	return magnusE0 * math.Exp(magnusA*t/(magnusB+t))
+}
+
+// Actual vapor pressure in Pascal, never negative.
+func vaporPressure(es, rh float64) float64 {
+	if rh <= 0 {
+		return 0
+	}
+	return es * rh / 100
+}
+
+// Vapor pressure should be positive.
+func dewPoint(e float64) float64 {
+	g := math.Log(e / magnusE0)
+	return magnusB * g / (magnusA - g)
+}
+
+// Vapor pressure should be positive.
+func frostPoint(e float64) float64 {
+	g := math.Log(e / magnusE0)
+	return magnusIceB * g / (magnusIceA - g)
+}
+
+func absoluteHumidity(t, e float64) float64 {
+	return e / (gasConstH2O * (t + 273.15)) * 1000
+}
+
+// Mixing ratio in kg/kg.
+func mixingRatio(e float64, pressurePa float32) float64 {
+	p := float64(pressurePa)
+	if p <= 0 {
+		p = StandardPressure
+	}
+	return 0.622 * e / (p - e)
+}
+
+// Enthalpy in kJ/kg for mixing ratio w in kg/kg.
+func enthalpy(t, w float64) float64 {
+	return 1.006*t + w*(2501+1.86*t)
+}
+
+// Calculate heat index according to NOAA algorithm
+// (Rothfusz regression with adjustments).
+func heatIndex(t, rh float64) float64 {
+	f := t*9/5 + 32
+	hi := 0.5 * (f + 61 + (f-68)*1.2 + rh*0.094)
+	if (hi+f)/2 >= 80 {
+		hi = -42.379 + 2.04901523*f + 10.14333127*rh -
+			0.22475541*f*rh - 0.00683783*f*f - 0.05481717*rh*rh +
+			0.00122874*f*f*rh + 0.00085282*f*rh*rh - 0.00000199*f*f*rh*rh
+		if rh < 13 && f >= 80 && f <= 112 {
+			hi -= (13 - rh) / 4 * math.Sqrt((17-math.Abs(f-95))/17)
+		} else if rh > 85 && f >= 80 && f <= 87 {
+			hi += (rh - 85) / 10 * (87 - f) / 5
+		}
+	}
+	return (hi - 32) * 5 / 9
+}
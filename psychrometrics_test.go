@@ -0,0 +1,119 @@
+package dht
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestPsychrometricsMatchHelpers(t *testing.T) {
+	for _, temp := range []float32{-40, -12.5, -0.1, 0, 3.2, 21.4, 35, 80} {
+		for _, hum := range []float32{0.1, 5, 33.3, 50, 78.9, 100} {
+			for _, pressure := range []float32{0, 85000, 101325} {
+				psy := Psychrometrics(temp, hum, pressure)
+				if psy.DewPoint == nil || *psy.DewPoint != DewPoint(temp, hum) {
+					t.Errorf("%v°C %v%%: dew point %v, want %v",
+						temp, hum, psy.DewPoint, DewPoint(temp, hum))
+				}
+				if temp < 0 {
+					if psy.FrostPoint == nil ||
+						*psy.FrostPoint != FrostPoint(temp, hum) {
+						t.Errorf("%v°C %v%%: frost point %v, want %v",
+							temp, hum, psy.FrostPoint, FrostPoint(temp, hum))
+					}
+				} else if psy.FrostPoint != nil {
+					t.Errorf("%v°C %v%%: unexpected frost point %v",
+						temp, hum, *psy.FrostPoint)
+				}
+				want := Psychro{DewPoint: psy.DewPoint,
+					FrostPoint:       psy.FrostPoint,
+					AbsoluteHumidity: AbsoluteHumidity(temp, hum),
+					MixingRatio:      MixingRatio(temp, hum, pressure),
+					Enthalpy:         Enthalpy(temp, hum, pressure),
+					VPD:              VPD(temp, hum),
+					HeatIndex:        HeatIndex(temp, hum)}
+				if psy != want {
+					t.Errorf("%v°C %v%% %vPa: got %+v, want %+v",
+						temp, hum, pressure, psy, want)
+				}
+			}
+		}
+	}
+}
+
+func TestPsychrometricsKnownValues(t *testing.T) {
+	psy := Psychrometrics(20, 50, 0)
+	within := func(got, want, tolerance float32) bool {
+		return math.Abs(float64(got-want)) <= float64(tolerance)
+	}
+	if !within(*psy.DewPoint, 9.26, 0.01) {
+		t.Errorf("dew point %v, want 9.26", *psy.DewPoint)
+	}
+	if !within(psy.AbsoluteHumidity, 8.63, 0.01) {
+		t.Errorf("absolute humidity %v, want 8.63", psy.AbsoluteHumidity)
+	}
+	if !within(psy.MixingRatio, 7.24, 0.01) {
+		t.Errorf("mixing ratio %v, want 7.24", psy.MixingRatio)
+	}
+	if !within(psy.VPD, 1.17, 0.01) {
+		t.Errorf("VPD %v, want 1.17", psy.VPD)
+	}
+}
+
+func TestPsychrometricsDryAir(t *testing.T) {
+	for _, temp := range []float32{-10, 25} {
+		psy := Psychrometrics(temp, 0, 0)
+		if psy.DewPoint != nil || psy.FrostPoint != nil {
+			t.Errorf("%v°C: got dew point %v, frost point %v for dry air",
+				temp, psy.DewPoint, psy.FrostPoint)
+		}
+		if psy.AbsoluteHumidity != 0 || psy.MixingRatio != 0 {
+			t.Errorf("%v°C: got %+v for dry air", temp, psy)
+		}
+		if _, err := json.Marshal(psy); err != nil {
+			t.Errorf("%v°C: %v", temp, err)
+		}
+		if !math.IsNaN(float64(DewPoint(temp, 0))) {
+			t.Errorf("%v°C: dew point %v, want NaN", temp, DewPoint(temp, 0))
+		}
+	}
+}
+
+func TestReadingPsychrometrics(t *testing.T) {
+	reading := Reading{Temperature: 23.4, Humidity: 61.2}
+	got, err := reading.Psychrometrics(97000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := Psychrometrics(23.4, 61.2, 97000); *got.DewPoint != *want.DewPoint ||
+		got.MixingRatio != want.MixingRatio {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	for _, reading := range []Reading{{humidityBad: true},
+		{temperatureBad: true}} {
+		if _, err := reading.Psychrometrics(0); !errors.Is(err, ErrInvalidField) {
+			t.Errorf("got %v, want %v", err, ErrInvalidField)
+		}
+	}
+}
+
+var psychroSink Psychro
+
+func BenchmarkPsychrometrics(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		psychroSink = Psychrometrics(21.4, 47.5, 0)
+	}
+}
+
+func BenchmarkPsychrometricsHelpers(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		dp := DewPoint(21.4, 47.5)
+		psychroSink = Psychro{DewPoint: &dp,
+			AbsoluteHumidity: AbsoluteHumidity(21.4, 47.5),
+			MixingRatio:      MixingRatio(21.4, 47.5, 0),
+			Enthalpy:         Enthalpy(21.4, 47.5, 0),
+			VPD:              VPD(21.4, 47.5),
+			HeatIndex:        HeatIndex(21.4, 47.5)}
+	}
+}
@@ -0,0 +1,106 @@
+package dht
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Bunch of pulses captured from sensor.
+type Pulses []Pulse
+
+// Decode 40 bits of payload (4 data bytes and control sum),
+// one bit per byte, most significant bit first.
+func (this Pulses) Bits() ([]byte, error) {
+	frame, err := alignFrame(this, false, getLogger())
+	if err != nil {
+		return nil, err
+	}
+	bits := make([]byte, len(frame)/2)
+	for i := range bits {
+		bits[i], err = decodeBit(frame, i*2)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return bits, nil
+}
+
+// Return durations of all pulses.
+func (this Pulses) Durations() []time.Duration {
+	durations := make([]time.Duration, len(this))
+	for i, pulse := range this {
+		durations[i] = pulse.Duration
+	}
+	return durations
+}
+
+// Return copy of pulses with inverted levels, for instance captured
+// on data line behind inverting level shifter.
+func (this Pulses) Invert() Pulses {
+	inverted := make(Pulses, len(this))
+	for i, pulse := range this {
+		inverted[i] = Pulse{Value: 1, Duration: pulse.Duration}
+		if pulse.Value != 0 {
+			inverted[i].Value = 0
+		}
+	}
+	return inverted
+}
+
+// Render pulses in one line, each pulse as level letter followed by
+// duration in microseconds: "L54 H70 L50 H24 ...".
+func (this Pulses) String() string {
+	var buf strings.Builder
+	for i, pulse := range this {
+		if i > 0 {
+			buf.WriteByte(' ')
+		}
+		if pulse.Value == 0 {
+			buf.WriteByte('L')
+		} else {
+			buf.WriteByte('H')
+		}
+		fmt.Fprintf(&buf, "%d", pulse.Duration.Round(time.Microsecond)/
+			time.Microsecond)
+	}
+	return buf.String()
+}
+
+// Width of histogram bucket.
+const histogramBucket = 10 * time.Microsecond
+
+// Number of high pulses which duration fall into [From, To).
+type HistogramBucket struct {
+	From  time.Duration
+	To    time.Duration
+	Count int
+}
+
+// Summarize high pulse durations in 10us buckets, skipping empty ones.
+// Trailing high pulse is idle line after response, which lasts until
+// capture ends, so it isn't counted. Well-formed frame give two
+// clusters: around 24us (bit 0) and 70us (bit 1).
+func (this Pulses) Histogram() []HistogramBucket {
+	pulses := this
+	if n := len(pulses); n > 0 && pulses[n-1].Value != 0 {
+		pulses = pulses[:n-1]
+	}
+	counts := make(map[int]int)
+	for _, pulse := range pulses {
+		if pulse.Value != 0 {
+			counts[int(pulse.Duration/histogramBucket)]++
+		}
+	}
+	hist := make([]HistogramBucket, 0, len(counts))
+	for i, count := range counts {
+		from := time.Duration(i) * histogramBucket
+		hist = append(hist, HistogramBucket{From: from,
+			To: from + histogramBucket, Count: count})
+	}
+	sort.Slice(hist, func(i, j int) bool {
+		return hist[i].From < hist[j].From
+	})
+	return hist
+}
@@ -0,0 +1,101 @@
+package dht
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Load pulses saved in the format of Pulses.String from testdata.
+func loadPulses(t *testing.T, name string) Pulses {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pulses, err := ParsePulses(string(data))
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	return pulses
+}
+
+func TestPulsesStringRoundTrip(t *testing.T) {
+	pulses := loadPulses(t, "dht22.txt")
+	data, err := os.ReadFile(filepath.Join("testdata", "dht22.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := pulses.String(); got != strings.TrimSpace(string(data)) {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestDecodeInvertedCapture(t *testing.T) {
+	want, err := DecodePulses(DHT22, loadPulses(t, "dht22.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want.Temperature != 21.3 || want.Humidity != 45.2 {
+		t.Fatalf("got %v %v, want 21.3°C 45.2%%", want.Temperature,
+			want.Humidity)
+	}
+	inverted := loadPulses(t, "dht22_inverted.txt")
+	if _, err := DecodePulses(DHT22, inverted); err == nil {
+		t.Errorf("inverted capture decoded as is")
+	}
+	got, err := DecodePulses(DHT22, inverted.Invert())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Temperature != want.Temperature || got.Humidity != want.Humidity {
+		t.Errorf("got %v %v, want %v %v", got.Temperature, got.Humidity,
+			want.Temperature, want.Humidity)
+	}
+}
+
+func TestPulsesBits(t *testing.T) {
+	bits, err := loadPulses(t, "dht22.txt").Bits()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := dht22Bytes(452, 213)
+	if len(bits) != 40 {
+		t.Fatalf("got %d bits, want 40", len(bits))
+	}
+	for i, bit := range bits {
+		if want := b[i/8] >> (7 - i%8) & 1; bit != want {
+			t.Errorf("bit %d: got %d, want %d", i, bit, want)
+		}
+	}
+	if _, err := loadPulses(t, "dht22.txt")[:40].Bits(); !errors.Is(err,
+		ErrBadPulseCount) {
+		t.Errorf("truncated pulses: got %v, want %v", err, ErrBadPulseCount)
+	}
+}
+
+func TestPulsesHistogram(t *testing.T) {
+	us := time.Microsecond
+	// Trailing 10ms idle pulse isn't counted
+	want := []HistogramBucket{{20 * us, 30 * us, 27}, {30 * us, 40 * us, 1},
+		{60 * us, 70 * us, 6}, {70 * us, 80 * us, 8}}
+	got := loadPulses(t, "dht22.txt").Histogram()
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	// Long glitch doesn't produce thousands of empty buckets
+	pulses := Pulses{{1, 25 * us}, {0, 50 * us}, {1, 5 * time.Millisecond},
+		{0, 50 * us}}
+	want = []HistogramBucket{{20 * us, 30 * us, 1},
+		{5 * time.Millisecond, 5*time.Millisecond + 10*us, 1}}
+	if got := pulses.Histogram(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got := (Pulses{}).Histogram(); len(got) != 0 {
+		t.Errorf("got %v for no pulses", got)
+	}
+}
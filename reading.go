@@ -0,0 +1,194 @@
+package dht
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Temperature and humidity decoded from sensor response.
+type Reading struct {
+	Temperature Temperature
+	Humidity    Humidity
+	// Values decoded from sensor response before calibration
+	// and compensation (see WithCalibration), same as above
+	// for uncalibrated sensor.
+	RawTemperature Temperature
+	RawHumidity    Humidity
+	// True, if humidity slightly above 100% was clamped to 100%
+	// (see WithClampHumidity).
+	Clamped bool
+	// True, if values were corrected for self-heating
+	// (see WithSelfHeatingCompensation).
+	Compensated bool
+	// Time when pulses received from sensor were decoded.
+	Time time.Time
+	// Sensor type reading was decoded for.
+	Sensor SensorType
+	// Pin number sensor connected to.
+	Pin int
+	// True, if sensor was read with boosted priority (see WithBoost).
+	Boosted bool
+	// Number of extra attempts made before successful read.
+	Retries int
+	// Outcome of every attempt, present only if some attempt failed.
+	Attempts []AttemptResult
+	// Stale reading discarded by double read (see WithDoubleRead),
+	// nil if extra read wasn't made or failed.
+	Discarded *Reading
+	// True, if reading was served from cache instead of sensor.
+	Stale bool
+	// Age of cached reading at the moment it was served.
+	Age time.Duration
+
+	// Fields found invalid, see TemperatureOK and HumidityOK.
+	temperatureBad bool
+	humidityBad    bool
+
+	// Values in tenths exactly as decoded from sensor words,
+	// valid only if exact is set.
+	temperatureDeci int16
+	humidityDeci    uint16
+	exact           bool
+}
+
+// Return false, if temperature is out of range sensor type is able
+// to measure. Such reading is returned only without WithStrictRange,
+// so humidity might still be used.
+func (this Reading) TemperatureOK() bool {
+	return !this.temperatureBad
+}
+
+// Return false, if humidity is out of range sensor type is able
+// to measure, or it's stuck (see WithStuckHumidity). Humidity clamped
+// to 100% is valid.
+func (this Reading) HumidityOK() bool {
+	return !this.humidityBad
+}
+
+// Return error wrapping ErrInvalidField, if temperature
+// or humidity is invalid.
+func (this Reading) checkFields() error {
+	if this.temperatureBad {
+		return fmt.Errorf("%w: temperature %v", ErrInvalidField,
+			this.Temperature)
+	}
+	if this.humidityBad {
+		return fmt.Errorf("%w: humidity %v", ErrInvalidField, this.Humidity)
+	}
+	return nil
+}
+
+// History of humidity, telling sensing element stuck at single value.
+type stuckDetector struct {
+	// Time humidity has the value since.
+	since    time.Time
+	humidity Humidity
+	// Temperature range observed since then.
+	minTemperature Temperature
+	maxTemperature Temperature
+}
+
+// Add reading to history. Return true, if humidity stays unchanged
+// at least period, while temperature changed.
+func (this *stuckDetector) observe(reading Reading, period time.Duration) bool {
+	t := reading.RawTemperature
+	if this.since.IsZero() || reading.RawHumidity != this.humidity {
+		*this = stuckDetector{since: reading.Time,
+			humidity: reading.RawHumidity, minTemperature: t,
+			maxTemperature: t}
+		return false
+	}
+	if t < this.minTemperature {
+		this.minTemperature = t
+	}
+	if t > this.maxTemperature {
+		this.maxTemperature = t
+	}
+	return reading.Time.Sub(this.since) >= period &&
+		this.maxTemperature > this.minTemperature
+}
+
+// Return temperature in tenths of degree Celsius. For uncorrected
+// reading it's the value sent by sensor, otherwise Temperature
+// rounded to tenths.
+func (this Reading) TemperatureDeci() int16 {
+	if this.exact {
+		return this.temperatureDeci
+	}
+	return int16(math.Round(float64(this.Temperature) * 10))
+}
+
+// Return relative humidity in tenths of percent, same way
+// as TemperatureDeci does.
+func (this Reading) HumidityDeci() uint16 {
+	if this.exact {
+		return this.humidityDeci
+	}
+	return uint16(math.Round(float64(this.Humidity) * 10))
+}
+
+// Return temperature and humidity in tenths, to encode reading
+// as integers instead of floats.
+func (this Reading) Deci() DeciReading {
+	return DeciReading{Temperature: this.TemperatureDeci(),
+		Humidity: this.HumidityDeci(), Time: this.Time}
+}
+
+// Return temperature in Fahrenheit.
+// Reading itself always keeps temperature in Celsius.
+func (this Reading) TemperatureF() float32 {
+	return this.Temperature.Fahrenheit()
+}
+
+// Return temperature in Kelvin.
+func (this Reading) TemperatureK() float32 {
+	return this.Temperature.Kelvin()
+}
+
+// Outcome of single read attempt.
+type AttemptResult struct {
+	// Time when attempt started.
+	Time time.Time
+	// How long attempt lasted.
+	Duration time.Duration
+	// Error if attempt failed.
+	Err error
+}
+
+// Reading with temperature and humidity kept in tenths.
+type DeciReading struct {
+	// Temperature in tenths of degree Celsius.
+	Temperature int16 `json:"temperature_deci"`
+	// Relative humidity in tenths of percent.
+	Humidity uint16 `json:"humidity_deci"`
+	// Time when reading was decoded.
+	Time time.Time `json:"time"`
+}
+
+// Size of binary encoded DeciReading.
+const deciReadingSize = 12
+
+// Encode as big-endian temperature, humidity and unix time
+// in milliseconds, 12 bytes in total.
+func (this DeciReading) MarshalBinary() ([]byte, error) {
+	b := make([]byte, deciReadingSize)
+	binary.BigEndian.PutUint16(b[0:], uint16(this.Temperature))
+	binary.BigEndian.PutUint16(b[2:], this.Humidity)
+	binary.BigEndian.PutUint64(b[4:], uint64(this.Time.UnixNano()/int64(time.Millisecond)))
+	return b, nil
+}
+
+// Decode reading encoded by MarshalBinary.
+func (this *DeciReading) UnmarshalBinary(data []byte) error {
+	if len(data) != deciReadingSize {
+		return fmt.Errorf("Binary reading should be %d bytes long, but %d found",
+			deciReadingSize, len(data))
+	}
+	this.Temperature = int16(binary.BigEndian.Uint16(data[0:]))
+	this.Humidity = binary.BigEndian.Uint16(data[2:])
+	ms := int64(binary.BigEndian.Uint64(data[4:]))
+	this.Time = time.Unix(0, ms*int64(time.Millisecond))
+	return nil
+}
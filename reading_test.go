@@ -0,0 +1,392 @@
+package dht
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	stdlog "log"
+	"math"
+	"testing"
+	"time"
+)
+
+// Expected DHT22 temperature in tenths for word sent by sensor.
+func dht22TemperatureDeci(word uint16, encoding NegativeEncoding) int16 {
+	magnitude := int16(word & 0x7FFF)
+	if word&0x8000 == 0 {
+		return magnitude
+	}
+	if encoding == TwosComplement ||
+		encoding == AutoDetect && magnitude > 400 {
+		return int16(word)
+	}
+	return -magnitude
+}
+
+func TestConvertBytesDHT22AllWords(t *testing.T) {
+	encodings := []NegativeEncoding{SignMagnitude, TwosComplement, AutoDetect}
+	for word := 0; word <= 0xFFFF; word++ {
+		hi, lo := byte(word>>8), byte(word)
+		for _, encoding := range encodings {
+			temp, hum, err := convertBytes(DHT22,
+				[5]byte{hi, lo, hi, lo}, encoding)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if hum != uint16(word) {
+				t.Fatalf("word %#04x: humidity %d, want %d", word, hum, word)
+			}
+			if want := dht22TemperatureDeci(uint16(word), encoding); temp != want {
+				t.Fatalf("word %#04x, encoding %v: temperature %d, want %d",
+					word, encoding, temp, want)
+			}
+		}
+	}
+}
+
+func TestDecodeNegativeEncodings(t *testing.T) {
+	// Temperature bytes sent by genuine sensor and by clone
+	// using two's complement.
+	genuine := [][2]byte{{0x80, 0x01}, {0x80, 0x69}, {0x81, 0x90}}
+	clone := [][2]byte{{0xFF, 0xFF}, {0xFF, 0x97}, {0xFE, 0x70}}
+	tests := []struct {
+		name     string
+		bytes    [][2]byte
+		encoding NegativeEncoding
+		want     []float32
+	}{
+		{"genuine, sign-magnitude", genuine, SignMagnitude,
+			[]float32{-0.1, -10.5, -40}},
+		{"genuine, two's complement", genuine, TwosComplement,
+			[]float32{-3276.7, -3266.3, -3236.8}},
+		{"genuine, auto", genuine, AutoDetect, []float32{-0.1, -10.5, -40}},
+		{"clone, sign-magnitude", clone, SignMagnitude,
+			[]float32{-3276.7, -3266.3, -3236.8}},
+		{"clone, two's complement", clone, TwosComplement,
+			[]float32{-0.1, -10.5, -40}},
+		{"clone, auto", clone, AutoDetect, []float32{-0.1, -10.5, -40}},
+	}
+	for _, test := range tests {
+		for i, tb := range test.bytes {
+			b := [5]byte{0x01, 0xC4, tb[0], tb[1]}
+			b[4] = b[0] + b[1] + b[2] + b[3]
+			reading, err := decodePulses(DHT22, responsePulses(b),
+				&config{logger: getLogger(), negativeEncoding: test.encoding})
+			if err != nil {
+				t.Fatalf("%s: %v", test.name, err)
+			}
+			if want := test.want[i]; float32(reading.Temperature) != want ||
+				reading.TemperatureDeci() != int16(math.Round(float64(want)*10)) {
+				t.Errorf("%s: %#02x %#02x: got %v (%d tenths), want %v",
+					test.name, tb[0], tb[1], reading.Temperature,
+					reading.TemperatureDeci(), want)
+			}
+		}
+	}
+}
+
+func TestConvertBytesDHT11AllWords(t *testing.T) {
+	for word := 0; word <= 0xFFFF; word++ {
+		hi, lo := byte(word>>8), byte(word)
+		temp, hum, err := convertBytes(DHT11, [5]byte{hi, lo, hi, lo},
+			SignMagnitude)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := uint16(hi)*10 + uint16(lo); hum != want {
+			t.Fatalf("word %#04x: humidity %d, want %d", word, hum, want)
+		}
+		want := int16(hi)*10 + int16(lo&0x0F)
+		if lo&0x80 != 0 {
+			want = -want
+		}
+		if temp != want {
+			t.Fatalf("word %#04x: temperature %d, want %d", word, temp, want)
+		}
+	}
+}
+
+func TestDecodeDHT11(t *testing.T) {
+	withSum := func(b [5]byte) [5]byte {
+		b[4] = b[0] + b[1] + b[2] + b[3]
+		return b
+	}
+	tests := []struct {
+		name string
+		b    [5]byte
+		temp Temperature
+		hum  Humidity
+	}{
+		{"old unit", withSum([5]byte{45, 0, 23, 0}), 23, 45},
+		{"old unit, dry", withSum([5]byte{20, 0, 0, 0}), 0, 20},
+		{"new unit", withSum([5]byte{45, 5, 23, 8}), 23.8, 45.5},
+		{"new unit, whole", withSum([5]byte{60, 0, 21, 0}), 21, 60},
+		{"new unit, negative", withSum([5]byte{52, 1, 1, 0x83}), -1.3, 52.1},
+		{"new unit, below zero", withSum([5]byte{80, 0, 0, 0x85}), -0.5, 80},
+	}
+	for _, test := range tests {
+		reading, err := DecodePulses(DHT11, responsePulses(test.b))
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if reading.Temperature != test.temp || reading.Humidity != test.hum {
+			t.Errorf("%s: got %v %v, want %v %v", test.name,
+				reading.Temperature, reading.Humidity, test.temp, test.hum)
+		}
+	}
+	temp, hum, err := DHT11.Resolution()
+	if err != nil || temp != 0.1 || hum != 0.1 {
+		t.Errorf("resolution %v°C %v%%, %v, want 0.1", temp, hum, err)
+	}
+}
+
+// Every temperature word decoded through the full path keeps exact
+// value in tenths, and float field rounds back to the same value.
+func TestReadingDeciAllTemperatures(t *testing.T) {
+	for word := 0; word <= 0xFFFF; word++ {
+		b := [5]byte{0x01, 0xC4, byte(word >> 8), byte(word)}
+		b[4] = b[0] + b[1] + b[2] + b[3]
+		reading, err := decodePulses(DHT22, responsePulses(b),
+			&config{logger: getLogger(), negativeEncoding: AutoDetect})
+		if err != nil {
+			t.Fatalf("word %#04x: %v", word, err)
+		}
+		want := dht22TemperatureDeci(uint16(word), AutoDetect)
+		if got := reading.TemperatureDeci(); got != want {
+			t.Fatalf("word %#04x: TemperatureDeci %d, want %d", word, got, want)
+		}
+		if got := int16(math.Round(float64(reading.Temperature) * 10)); got != want {
+			t.Fatalf("word %#04x: Temperature %v rounds to %d, want %d",
+				word, reading.Temperature, got, want)
+		}
+		if got := reading.HumidityDeci(); got != 452 {
+			t.Fatalf("word %#04x: HumidityDeci %d, want 452", word, got)
+		}
+	}
+}
+
+func TestReadingDeciCorrected(t *testing.T) {
+	b := dht22Bytes(452, 213)
+	cfg := &config{logger: getLogger()}
+	if err := WithCalibration(0.5, 1, -1, 1)(cfg); err != nil {
+		t.Fatal(err)
+	}
+	reading, err := decodePulses(DHT22, responsePulses(b), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := reading.TemperatureDeci(); got != 218 {
+		t.Errorf("TemperatureDeci %d, want 218", got)
+	}
+	if got := reading.HumidityDeci(); got != 442 {
+		t.Errorf("HumidityDeci %d, want 442", got)
+	}
+
+	// Reading built by hand has no exact values
+	reading = Reading{Temperature: -21.4, Humidity: 45.2}
+	if got := reading.TemperatureDeci(); got != -214 {
+		t.Errorf("TemperatureDeci %d, want -214", got)
+	}
+	if got := reading.HumidityDeci(); got != 452 {
+		t.Errorf("HumidityDeci %d, want 452", got)
+	}
+}
+
+func TestDeciReadingEncoding(t *testing.T) {
+	deci := DeciReading{Temperature: -214, Humidity: 452,
+		Time: time.Unix(1700000000, 123*int64(time.Millisecond))}
+
+	data, err := json.Marshal(deci)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		t.Fatal(err)
+	}
+	if m["temperature_deci"] != float64(-214) || m["humidity_deci"] != float64(452) {
+		t.Errorf("JSON %s, want integer tenths", data)
+	}
+
+	data, err = deci.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded DeciReading
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Temperature != deci.Temperature ||
+		decoded.Humidity != deci.Humidity || !decoded.Time.Equal(deci.Time) {
+		t.Errorf("binary round trip %+v, want %+v", decoded, deci)
+	}
+	if err := decoded.UnmarshalBinary(data[:4]); err == nil {
+		t.Error("short binary reading decoded without error")
+	}
+}
+
+// Build 5 bytes sent by DHT11 for humidity and temperature
+// in whole units, including checksum.
+func dht11Bytes(hum, temp byte) [5]byte {
+	return [5]byte{hum, 0, temp, 0, hum + temp}
+}
+
+func TestFieldValidity(t *testing.T) {
+	tests := []struct {
+		name       string
+		sensorType SensorType
+		b          [5]byte
+		tempOK     bool
+		humOK      bool
+	}{
+		{"DHT11 valid", DHT11, dht11Bytes(45, 23), true, true},
+		{"DHT11 too hot", DHT11, dht11Bytes(45, 60), false, true},
+		{"DHT11 too dry", DHT11, dht11Bytes(10, 23), true, false},
+		{"DHT11 both", DHT11, dht11Bytes(95, 55), false, false},
+		{"DHT22 valid", DHT22, dht22Bytes(452, 213), true, true},
+		{"DHT22 too cold", DHT22, dht22Bytes(452, -450), false, true},
+	}
+	for _, test := range tests {
+		reading, err := DecodePulses(test.sensorType, responsePulses(test.b))
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if reading.TemperatureOK() != test.tempOK ||
+			reading.HumidityOK() != test.humOK {
+			t.Errorf("%s: temperature valid %v, humidity valid %v, want %v, %v",
+				test.name, reading.TemperatureOK(), reading.HumidityOK(),
+				test.tempOK, test.humOK)
+		}
+		_, err = reading.Psychrometrics(0)
+		if (test.tempOK && test.humOK) == errors.Is(err, ErrInvalidField) {
+			t.Errorf("%s: psychrometrics: got %v", test.name, err)
+		}
+		// Strict range rejects reading with the first offending field
+		var specErr *OutOfSpecError
+		err = reading.Validate()
+		if !test.tempOK && (!errors.As(err, &specErr) ||
+			specErr.Field != "temperature") ||
+			test.tempOK && !test.humOK && (!errors.As(err, &specErr) ||
+				specErr.Field != "humidity") {
+			t.Errorf("%s: validate: got %v", test.name, err)
+		}
+	}
+	// Zero value is valid
+	if reading := (Reading{}); !reading.TemperatureOK() || !reading.HumidityOK() {
+		t.Error("zero reading has invalid field")
+	}
+}
+
+// Measurement range bounds are inclusive.
+func TestValidateBounds(t *testing.T) {
+	tests := []struct {
+		name      string
+		reading   Reading
+		wantField string
+		wantLimit float32
+	}{
+		{"DHT11 0°C", Reading{Sensor: DHT11, Temperature: 0, Humidity: 45}, "", 0},
+		{"DHT11 50°C", Reading{Sensor: DHT11, Temperature: 50, Humidity: 45}, "", 0},
+		{"DHT11 20%", Reading{Sensor: DHT11, Temperature: 23, Humidity: 20}, "", 0},
+		{"DHT11 90%", Reading{Sensor: DHT11, Temperature: 23, Humidity: 90}, "", 0},
+		{"DHT11 -0.1°C", Reading{Sensor: DHT11, Temperature: -0.1, Humidity: 45},
+			"temperature", 0},
+		{"DHT11 50.1°C", Reading{Sensor: DHT11, Temperature: 50.1, Humidity: 45},
+			"temperature", 50},
+		{"DHT22 -40°C", Reading{Sensor: DHT22, Temperature: -40, Humidity: 45}, "", 0},
+		{"DHT22 80°C", Reading{Sensor: DHT22, Temperature: 80, Humidity: 45}, "", 0},
+		{"DHT22 0%", Reading{Sensor: DHT22, Temperature: 21, Humidity: 0}, "", 0},
+		{"DHT22 100%", Reading{Sensor: DHT22, Temperature: 21, Humidity: 100}, "", 0},
+		{"DHT22 -40.1°C", Reading{Sensor: DHT22, Temperature: -40.1, Humidity: 45},
+			"temperature", -40},
+		{"DHT22 80.1°C", Reading{Sensor: DHT22, Temperature: 80.1, Humidity: 45},
+			"temperature", 80},
+		{"DHT22 100.1%", Reading{Sensor: DHT22, Temperature: 21, Humidity: 100.1},
+			"humidity", 100},
+	}
+	for _, test := range tests {
+		err := test.reading.Validate()
+		if test.wantField == "" {
+			if err != nil {
+				t.Errorf("%s: %v", test.name, err)
+			}
+			continue
+		}
+		var specErr *OutOfSpecError
+		if !errors.As(err, &specErr) {
+			t.Errorf("%s: got %v, want %v", test.name, err, ErrOutOfSpec)
+			continue
+		}
+		if specErr.Field != test.wantField || specErr.Min != test.wantLimit &&
+			specErr.Max != test.wantLimit {
+			t.Errorf("%s: got %s range %v..%v, want %s limit %v", test.name,
+				specErr.Field, specErr.Min, specErr.Max, test.wantField,
+				test.wantLimit)
+		}
+	}
+}
+
+func TestStuckDetector(t *testing.T) {
+	const period = 10 * time.Minute
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := func(minutes int, temp Temperature, hum Humidity) Reading {
+		return Reading{Time: start.Add(time.Duration(minutes) * time.Minute),
+			RawTemperature: temp, RawHumidity: hum}
+	}
+	tests := []struct {
+		name     string
+		readings []Reading
+		// Whether humidity is stuck after each reading.
+		want []bool
+	}{
+		{"stuck", []Reading{at(0, 20, 1), at(5, 20.5, 1), at(10, 21, 1),
+			at(15, 21.5, 1)}, []bool{false, false, true, true}},
+		{"constant temperature", []Reading{at(0, 20, 1), at(10, 20, 1),
+			at(20, 20, 1)}, []bool{false, false, false}},
+		{"humidity changed", []Reading{at(0, 20, 1), at(9, 21, 1),
+			at(10, 22, 1.1), at(19, 23, 1.1), at(20, 24, 1.1)},
+			[]bool{false, false, false, false, true}},
+		{"temperature back", []Reading{at(0, 20, 50), at(5, 21, 50),
+			at(10, 20, 50)}, []bool{false, false, true}},
+	}
+	for _, test := range tests {
+		var stuck stuckDetector
+		for i, reading := range test.readings {
+			if got := stuck.observe(reading, period); got != test.want[i] {
+				t.Errorf("%s: reading %d: stuck %v, want %v", test.name, i,
+					got, test.want[i])
+			}
+		}
+	}
+}
+
+var readingSink Reading
+
+// Decoding captured pulses as live read does, with pulse dump skipped
+// (debug off) or built (debug on).
+func BenchmarkDecode(b *testing.B) {
+	pulses := responsePulses(dht22Bytes(452, 213))
+	for _, debug := range []bool{false, true} {
+		name := "debug off"
+		if debug {
+			name = "debug on"
+		}
+		b.Run(name, func(b *testing.B) {
+			// Logger writing to io.Discard itself doesn't format output
+			w := struct{ io.Writer }{io.Discard}
+			cfg, err := newConfig(DHT22, []Option{
+				WithLogger(NewStdLogger(stdlog.New(w, "", 0), debug))})
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				printPulseArrayForDebug(cfg.logger, pulses)
+				readingSink, err = decodeChecked(DHT22, pulses, cfg)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
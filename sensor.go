@@ -0,0 +1,442 @@
+package dht
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/kidoman/embd"
+)
+
+// Returned when Sensor is used after Close.
+var ErrSensorClosed = errors.New("Sensor is closed")
+
+// Time of last successful read per pin, shared by all sensors,
+// since ReadDHTxx and Read open new Sensor for each call.
+var lastReads = struct {
+	sync.Mutex
+	m map[int]time.Time
+}{m: make(map[int]time.Time)}
+
+// Return how long to wait before pin could be read again.
+func remainingInterval(pin int, interval time.Duration) time.Duration {
+	lastReads.Lock()
+	defer lastReads.Unlock()
+	last, ok := lastReads.m[pin]
+	if !ok {
+		return 0
+	}
+	return interval - time.Since(last)
+}
+
+func recordRead(pin int, t time.Time) {
+	lastReads.Lock()
+	defer lastReads.Unlock()
+	lastReads.m[pin] = t
+}
+
+// Sensor keep GPIO pin opened between reads, so periodic polling
+// doesn't initialize GPIO and export pin again and again.
+// Sensor is safe for concurrent use, reads are serialized.
+type Sensor struct {
+	sensorType SensorType
+	pin        int
+	cfg        *config
+
+	mu sync.Mutex
+	p  embd.DigitalPin
+	// True, if pin was opened by sensor and should be closed on Close.
+	ownPin bool
+	// True, if sensor acquired GPIO and should release it on Close.
+	ownGPIO bool
+	values  []int64
+	closed  bool
+	// Time when previous capture ended.
+	lastCapture time.Time
+	// True, if line is held or pulled high since previous capture.
+	lineHigh bool
+	// True, if sensor type is unknown yet and should be detected
+	// from first valid response (see ReadAuto).
+	detect bool
+	// History of humidity values, if stuck humidity is detected.
+	stuck stuckDetector
+}
+
+// Initialize GPIO and open pin connected to sensor.
+// Call Close to release pin when sensor is not needed anymore.
+// GPIO is shared by all sensors and closed when last of them is closed,
+// use WithExternalGPIO option if GPIO is initialized by application.
+func NewSensor(sensorType SensorType, pin int, opts ...Option) (*Sensor, error) {
+	cfg, err := newConfig(sensorType, opts)
+	if err != nil {
+		return nil, err
+	}
+	// Initialize the GPIO interface
+	if !cfg.externalGPIO {
+		if err := acquireGPIO(); err != nil {
+			return nil, err
+		}
+	}
+	// Open pin
+	p, err := newDigitalPin(pin)
+	if err != nil {
+		err = newGPIOError(err, "open pin %d", pin)
+		if !cfg.externalGPIO {
+			err = errors.Join(err, releaseGPIO())
+		}
+		return nil, err
+	}
+	sensor := newSensor(sensorType, p, cfg)
+	sensor.ownPin = true
+	sensor.ownGPIO = !cfg.externalGPIO
+	return sensor, nil
+}
+
+// Create sensor on top of pin opened by caller, who keeps
+// GPIO lifecycle: neither GPIO initialization is made here,
+// nor pin is closed on Close.
+func NewSensorFromPin(sensorType SensorType, p embd.DigitalPin,
+	opts ...Option) (*Sensor, error) {
+	if p == nil {
+		return nil, fmt.Errorf("%w: pin can't be nil", ErrInvalidOption)
+	}
+	cfg, err := newConfig(sensorType, opts)
+	if err != nil {
+		return nil, err
+	}
+	return newSensor(sensorType, p, cfg), nil
+}
+
+func newSensor(sensorType SensorType, p embd.DigitalPin, cfg *config) *Sensor {
+	sensor := &Sensor{sensorType: sensorType, pin: p.N(), cfg: cfg}
+	sensor.setPin(p)
+	return sensor
+}
+
+// Use pin p, inverting its levels if configured.
+func (this *Sensor) setPin(p embd.DigitalPin) {
+	if this.cfg.invertedSignal {
+		p = invertedPin{p}
+	}
+	this.p = p
+}
+
+// Return sensor type.
+func (this *Sensor) Type() SensorType {
+	return this.sensorType
+}
+
+// Return pin number sensor connected to.
+func (this *Sensor) Pin() int {
+	return this.pin
+}
+
+// Activate sensor, read and decode temperature and humidity.
+func (this *Sensor) Read() (Reading, error) {
+	return this.ReadContext(context.Background())
+}
+
+// Same as Read, but stop as soon as ctx is done.
+func (this *Sensor) ReadContext(ctx context.Context) (Reading, error) {
+	reading, _, err := this.readWithRetry(ctx)
+	return reading, err
+}
+
+// Read sensor retrying as many times as configured.
+// Return reading with number of extra retries made.
+// If any attempt failed, outcome of all attempts is kept in
+// Reading.Attempts, or in RetryError when no attempt succeeded.
+func (this *Sensor) readWithRetry(ctx context.Context) (reading Reading,
+	retried int, err error) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	retry := this.cfg.retries
+	delay := this.cfg.retryDelay
+	if this.cfg.maxDelay > 0 && delay > this.cfg.maxDelay {
+		delay = this.cfg.maxDelay
+	}
+	if err := this.waitInterval(ctx); err != nil {
+		return Reading{}, 0, err
+	}
+	discarded, err := this.prime(ctx)
+	if err != nil {
+		return Reading{}, 0, err
+	}
+	var attempts []AttemptResult
+	for {
+		start := time.Now()
+		reading, err := this.read(ctx)
+		if err != nil || attempts != nil {
+			if attempts == nil {
+				attempts = make([]AttemptResult, 0, retry+1)
+			}
+			attempts = append(attempts, AttemptResult{Time: start,
+				Duration: time.Since(start), Err: err})
+		}
+		if err != nil {
+			if retry > 0 && ctx.Err() == nil && IsTransient(err) {
+				logWarning(this.cfg.logger, "Read failed", "pin", this.pin,
+					"sensor", this.sensorType, "error_kind", Kind(err),
+					"error", err)
+				// Sleep before new attempt, count it only once sleep is over,
+				// so cancelled pause doesn't count as retry
+				if err := pause(ctx, delay); err != nil {
+					return Reading{}, retried, err
+				}
+				retry--
+				retried++
+				delay = this.cfg.nextRetryDelay(delay)
+				continue
+			}
+			if len(attempts) > 1 {
+				err = &RetryError{Attempts: attempts}
+			}
+			return Reading{}, retried, err
+		}
+		recordRead(this.pin, reading.Time)
+		reading.Retries = retried
+		reading.Attempts = attempts
+		reading.Discarded = discarded
+		return reading, retried, nil
+	}
+}
+
+// Make extra read discarded by double read, unless pin was read recently,
+// and wait minimum interval after it. Return discarded reading, if any.
+// Only cancellation is reported as error. Caller should hold the lock.
+func (this *Sensor) prime(ctx context.Context) (*Reading, error) {
+	if !this.cfg.doubleRead ||
+		remainingInterval(this.pin, 2*this.cfg.minInterval) > 0 {
+		return nil, nil
+	}
+	var discarded *Reading
+	reading, err := this.read(ctx)
+	if err == nil {
+		discarded = &reading
+	} else if ctx.Err() != nil {
+		return nil, contextError(ctx)
+	} else {
+		// Sensor got start signal anyway, so it measured values
+		logDebug(this.cfg.logger, "Extra read failed", "pin", this.pin,
+			"sensor", this.sensorType, "error", err)
+	}
+	if err := pause(ctx, this.cfg.minInterval); err != nil {
+		return nil, err
+	}
+	return discarded, nil
+}
+
+// Respect minimum interval since previous successful read:
+// either wait or fail depending on options. Caller should hold the lock.
+func (this *Sensor) waitInterval(ctx context.Context) error {
+	wait := remainingInterval(this.pin, this.cfg.minInterval)
+	if wait <= 0 {
+		return nil
+	}
+	if !this.cfg.waitInterval {
+		return &TooSoonError{Remaining: wait}
+	}
+	return pause(ctx, wait)
+}
+
+// Activate sensor and read 5 raw bytes without conversion and retries.
+// Control sum mismatch is reported with ChecksumError,
+// but bytes are returned anyway.
+func (this *Sensor) ReadRaw() ([5]byte, error) {
+	ctx := context.Background()
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	if err := this.waitInterval(ctx); err != nil {
+		return [5]byte{}, err
+	}
+	pulses, _, err := this.capture(ctx)
+	if err != nil {
+		return [5]byte{}, err
+	}
+	b, err := decodeBytes(pulses, this.cfg.strictFrame, this.cfg.logger)
+	if err == nil || errors.Is(err, ErrChecksum) {
+		recordRead(this.pin, time.Now())
+	}
+	return b, err
+}
+
+// Activate sensor and capture response pulses within timeout.
+// Return whether capture was made with boosted priority.
+// Caller should hold the lock.
+func (this *Sensor) capture(ctx context.Context) ([]Pulse, bool, error) {
+	if this.closed {
+		return nil, false, ErrSensorClosed
+	}
+	readCtx, cancel := context.WithTimeout(ctx, this.cfg.timeout)
+	defer cancel()
+	// Skip hold, if line was actively held or pulled high since previous
+	// capture and sensor had minimum interval to settle. Released line
+	// may float without external pull-up resistor, so it isn't trusted.
+	signal := this.cfg.startSignal
+	if this.lineHigh && time.Since(this.lastCapture) > this.cfg.minInterval {
+		signal.hold = 0
+	}
+	defer func() {
+		this.lastCapture = time.Now()
+		// Failure to restore bus shouldn't discard reading
+		if err := this.setIdle(); err != nil {
+			logWarning(this.cfg.logger, "Can't restore idle bus state",
+				"pin", this.pin, "sensor", this.sensorType, "error", err)
+		}
+	}()
+	// Activate sensor and read data to pulses array
+	boost := this.cfg.boost
+	dial := func() (Pulses, error) {
+		return dialDHTxxAndGetResponse(readCtx, this.p, boost, signal,
+			this.cfg.captureLimits, &this.values)
+	}
+	pulses, err := dial()
+	if err != nil && this.cfg.boostFallback && errors.Is(err, ErrBoostUnavailable) {
+		logWarning(this.cfg.logger, "Read without boost", "pin", this.pin,
+			"sensor", this.sensorType, "error", err)
+		boost = false
+		pulses, err = dial()
+	}
+	var gpioErr *GPIOError
+	if err != nil && this.ownPin && errors.As(err, &gpioErr) {
+		// Pin might disappear, for instance unexported externally
+		// via sysfs: reopen it once and try again
+		logWarning(this.cfg.logger, "Reopen pin after failure",
+			"pin", this.pin, "sensor", this.sensorType, "error", err)
+		if err = this.reopen(); err == nil {
+			pulses, err = dial()
+		}
+	}
+	if err != nil {
+		// Tell own deadline from caller's one
+		if ctx.Err() == nil && readCtx.Err() != nil {
+			return nil, false, fmt.Errorf("%w: read didn't complete in %v",
+				ErrTimeout, this.cfg.timeout)
+		}
+		return nil, false, err
+	}
+	// Output debug information, unless pulses are dumped on failure only
+	if !this.cfg.pulseDump {
+		printPulseArrayForDebug(this.cfg.logger, pulses)
+	}
+	return pulses, boost, nil
+}
+
+// Close and open pin again. Caller should hold the lock.
+func (this *Sensor) reopen() error {
+	var closeErr error
+	if err := this.p.Close(); err != nil {
+		closeErr = newGPIOError(err, "close pin %d", this.pin)
+	}
+	p, err := newDigitalPin(this.pin)
+	if err != nil {
+		return errors.Join(newGPIOError(err, "reopen pin %d", this.pin),
+			closeErr)
+	}
+	this.setPin(p)
+	return nil
+}
+
+// State data line is left in between reads.
+type IdlePolicy int
+
+const (
+	// Input, relying on external pull-up resistor.
+	IdleInput IdlePolicy = iota
+	// Input with internal pull-up enabled, where platform supports it,
+	// otherwise same as IdleInput.
+	IdlePullUp
+	// Output driven high, for boards without pull-up resistor.
+	IdleHigh
+)
+
+// Put data line to idle state according to policy.
+// Caller should hold the lock.
+func (this *Sensor) setIdle() error {
+	this.lineHigh = false
+	if this.cfg.idlePolicy == IdleHigh {
+		if err := this.p.SetDirection(embd.Out); err != nil {
+			return newGPIOError(err, "set pin %d direction", this.pin)
+		}
+		if err := this.p.Write(embd.High); err != nil {
+			return newGPIOError(err, "set pin %d high", this.pin)
+		}
+		this.lineHigh = true
+		return nil
+	}
+	if err := this.p.SetDirection(embd.In); err != nil {
+		return newGPIOError(err, "set pin %d direction", this.pin)
+	}
+	if this.cfg.idlePolicy == IdlePullUp {
+		// Not every GPIO driver implement pull-up
+		if err := this.p.PullUp(); err != nil {
+			logDebug(this.cfg.logger, "Can't enable pull-up", "pin", this.pin,
+				"error", err)
+		} else {
+			// Behind inverting level shifter pull-up holds line low
+			this.lineHigh = !this.cfg.invertedSignal
+		}
+	}
+	return nil
+}
+
+// Make single read attempt. Caller should hold the lock.
+func (this *Sensor) read(ctx context.Context) (Reading, error) {
+	pulses, boosted, err := this.capture(ctx)
+	if err != nil {
+		return Reading{}, err
+	}
+	if this.detect {
+		b, err := decodeBytes(pulses, this.cfg.strictFrame, this.cfg.logger)
+		if err != nil {
+			return Reading{}, err
+		}
+		this.sensorType = sensorTypeFromBytes(b)
+		this.detect = false
+		logDebug(this.cfg.logger, "Sensor type detected", "pin", this.pin,
+			"sensor", this.sensorType)
+	}
+	// Decode pulses
+	reading, err := decodeChecked(this.sensorType, pulses, this.cfg)
+	if err != nil {
+		if this.cfg.pulseDump && !errors.Is(err, ErrOutOfSpec) {
+			logWarning(this.cfg.logger, "Can't decode pulses", "pin", this.pin,
+				"sensor", this.sensorType, "pulse_count", len(pulses),
+				"pulses", pulses, "error", err)
+		}
+		return Reading{}, err
+	}
+	if this.cfg.stuckHumidity > 0 &&
+		this.stuck.observe(reading, this.cfg.stuckHumidity) {
+		reading.humidityBad = true
+	}
+	reading.Pin = this.pin
+	reading.Boosted = boosted
+	return reading, nil
+}
+
+// Release pin and GPIO. Safe to call more than once.
+// Pin passed to NewSensorFromPin is left opened, GPIO is closed
+// only if no other sensor use it.
+func (this *Sensor) Close() error {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	if this.closed {
+		return nil
+	}
+	this.closed = true
+	this.values = nil
+	err := this.setIdle()
+	if !this.ownPin {
+		return err
+	}
+	if closeErr := this.p.Close(); closeErr != nil {
+		err = errors.Join(err, newGPIOError(closeErr, "close pin %d", this.pin))
+	}
+	if this.ownGPIO {
+		err = errors.Join(err, releaseGPIO())
+	}
+	return err
+}
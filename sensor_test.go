@@ -0,0 +1,597 @@
+package dht
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kidoman/embd"
+)
+
+func TestIdlePolicy(t *testing.T) {
+	tests := []struct {
+		policy IdlePolicy
+		dir    embd.Direction
+		last   string
+	}{
+		{IdleInput, embd.In, "SetDirection"},
+		{IdlePullUp, embd.In, "PullUp"},
+		{IdleHigh, embd.Out, "Write"},
+	}
+	for _, test := range tests {
+		pin := newMockPin(nil, 1)
+		sensor, err := NewSensorFromPin(DHT22, pin,
+			quickOptions(WithIdlePolicy(test.policy))...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		check := func(when string) {
+			t.Helper()
+			if pin.dir != test.dir || pin.lastCall() != test.last {
+				t.Errorf("policy %d after %s: direction %v, last call %s, "+
+					"want %v, %s", test.policy, when, pin.dir, pin.lastCall(),
+					test.dir, test.last)
+			}
+			if test.policy == IdleHigh && pin.out != embd.High {
+				t.Errorf("policy %d after %s: line driven low",
+					test.policy, when)
+			}
+		}
+		if _, err := sensor.Read(); !errors.Is(err, ErrNoResponse) {
+			t.Fatalf("got %v, want %v", err, ErrNoResponse)
+		}
+		check("read")
+		if err := sensor.Close(); err != nil {
+			t.Fatal(err)
+		}
+		check("close")
+	}
+}
+
+func TestStartHold(t *testing.T) {
+	const hold = 300 * time.Millisecond
+	tests := []struct {
+		name   string
+		policy IdlePolicy
+		pullUp error
+		skip   bool
+	}{
+		{"input", IdleInput, nil, false},
+		{"pull-up", IdlePullUp, nil, true},
+		{"pull-up unsupported", IdlePullUp, errors.New("not supported"), false},
+		{"high", IdleHigh, nil, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pin := newMockPin(nil, 1)
+			pin.fail["PullUp"] = test.pullUp
+			sensor, err := NewSensorFromPin(DHT22, pin,
+				quickOptions(WithIdlePolicy(test.policy), WithStartHold(hold))...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// First capture always hold the line
+			start := time.Now()
+			sensor.capture(context.Background())
+			if elapsed := time.Since(start); elapsed < hold {
+				t.Fatalf("first capture took %v, want hold", elapsed)
+			}
+			// Pretend minimum interval is over
+			sensor.lastCapture = time.Now().Add(-time.Hour)
+			start = time.Now()
+			sensor.capture(context.Background())
+			if skipped := time.Since(start) < hold; skipped != test.skip {
+				t.Errorf("hold skipped: %v, want %v", skipped, test.skip)
+			}
+		})
+	}
+}
+
+func TestNewSensorOpenFailure(t *testing.T) {
+	openErr := errors.New("open failed")
+	closeErr := errors.New("close failed")
+	for _, gpioCloseErr := range []error{nil, closeErr} {
+		fake := installFakeGPIO(t)
+		fake.openErr = openErr
+		fake.closeErr = gpioCloseErr
+		sensor, err := NewSensor(DHT22, 4)
+		if sensor != nil || !errors.Is(err, openErr) || Kind(err) != KindGPIO {
+			t.Errorf("got %v, %v, want GPIO error", sensor, err)
+		}
+		if (gpioCloseErr != nil) != errors.Is(err, closeErr) {
+			t.Errorf("got %v, want GPIO close failure joined: %v", err,
+				gpioCloseErr != nil)
+		}
+		if fake.inits != 1 || fake.closes != 1 {
+			t.Errorf("GPIO initialized %d times, closed %d times, want 1",
+				fake.inits, fake.closes)
+		}
+	}
+}
+
+func TestSensorClose(t *testing.T) {
+	injected := errors.New("injected")
+	tests := []struct {
+		name string
+		fail string
+	}{
+		{"success", ""},
+		{"release failure", "SetDirection"},
+		{"close failure", "Close"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pin := newMockPin(nil, 1)
+			fake := installFakeGPIO(t, pin)
+			sensor, err := NewSensor(DHT22, pin.N())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.fail != "" {
+				pin.fail[test.fail] = injected
+			}
+			err = sensor.Close()
+			if (test.fail != "") != errors.Is(err, injected) {
+				t.Errorf("got %v, want injected failure: %v", err,
+					test.fail != "")
+			}
+			if err := sensor.Close(); err != nil {
+				t.Errorf("second Close: %v", err)
+			}
+			if n := pin.count("Close"); n != 1 {
+				t.Errorf("pin closed %d times, want 1", n)
+			}
+			if fake.closes != 1 {
+				t.Errorf("GPIO closed %d times, want 1", fake.closes)
+			}
+			if _, err := sensor.Read(); !errors.Is(err, ErrSensorClosed) {
+				t.Errorf("read after Close: got %v, want %v", err,
+					ErrSensorClosed)
+			}
+		})
+	}
+}
+
+// GPIO is initialized and closed once per Sensor, or once per read
+// by functions opening pin themselves, never in the middle of a read.
+func TestGPIOInitCloseCount(t *testing.T) {
+	const reads = 3
+	wave := responseWave(dht22Bytes(452, 213))
+	opts := quickOptions(WithMinIntervalWait())
+	sensorReads := func(opts ...Option) func(t *testing.T, fake *fakeGPIO) {
+		return func(t *testing.T, fake *fakeGPIO) {
+			sensor, err := NewSensor(DHT22, 4, opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < reads; i++ {
+				if _, err := sensor.Read(); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if fake.closes != 0 {
+				t.Errorf("GPIO closed %d times before Close", fake.closes)
+			}
+			if err := sensor.Close(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	tests := []struct {
+		name          string
+		pins          int
+		run           func(t *testing.T, fake *fakeGPIO)
+		inits, closes int
+	}{
+		{"Sensor", 1, sensorReads(opts...), 1, 1},
+		{"Sensor with external GPIO", 1,
+			sensorReads(append(opts, WithExternalGPIO())...), 0, 0},
+		{"Read", reads, func(t *testing.T, fake *fakeGPIO) {
+			for i := 0; i < reads; i++ {
+				if _, err := Read(DHT22, 4, opts...); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}, reads, reads},
+		{"ReadDHTxx", reads, func(t *testing.T, fake *fakeGPIO) {
+			for i := 0; i < reads; i++ {
+				if _, _, err := ReadDHTxx(DHT22, 4, false); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}, reads, reads},
+		{"ReadFromPin", 0, func(t *testing.T, fake *fakeGPIO) {
+			pin := newMockPin(wave, 1)
+			for i := 0; i < reads; i++ {
+				if _, err := ReadFromPin(DHT22, pin, opts...); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}, 0, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			installFakeClock(t)
+			var pins []*mockPin
+			for i := 0; i < test.pins; i++ {
+				pins = append(pins, newMockPin(wave, 1))
+			}
+			fake := installFakeGPIO(t, pins...)
+			test.run(t, fake)
+			if fake.inits != test.inits || fake.closes != test.closes {
+				t.Errorf("GPIO initialized %d times, closed %d times, "+
+					"want %d, %d", fake.inits, fake.closes, test.inits,
+					test.closes)
+			}
+			for i, pin := range pins {
+				if n := pin.count("Close"); n != 1 {
+					t.Errorf("pin %d closed %d times, want 1", i, n)
+				}
+			}
+		})
+	}
+}
+
+// Pin of another component, which application opened through embd,
+// survives sensor read, unless GPIO is closed by legacy functions.
+func TestOtherPinSurvivesRead(t *testing.T) {
+	wave := responseWave(dht22Bytes(452, 213))
+	opts := quickOptions(WithMinIntervalWait())
+	must := func(t *testing.T, err error) {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		name     string
+		run      func(t *testing.T)
+		survives bool
+	}{
+		{"Sensor with external GPIO", func(t *testing.T) {
+			sensor, err := NewSensor(DHT22, 4,
+				append(opts, WithExternalGPIO())...)
+			must(t, err)
+			_, err = sensor.Read()
+			must(t, err)
+			must(t, sensor.Close())
+		}, true},
+		{"Read with external GPIO", func(t *testing.T) {
+			_, err := Read(DHT22, 4, append(opts, WithExternalGPIO())...)
+			must(t, err)
+		}, true},
+		{"ReadFromPin", func(t *testing.T) {
+			_, err := ReadFromPin(DHT22, newMockPin(wave, 1), opts...)
+			must(t, err)
+		}, true},
+		{"Sensor", func(t *testing.T) {
+			sensor, err := NewSensor(DHT22, 4, opts...)
+			must(t, err)
+			_, err = sensor.Read()
+			must(t, err)
+			must(t, sensor.Close())
+		}, false},
+		{"Read", func(t *testing.T) {
+			_, err := Read(DHT22, 4, opts...)
+			must(t, err)
+		}, false},
+		{"ReadDHTxx", func(t *testing.T) {
+			_, _, err := ReadDHTxx(DHT22, 4, false)
+			must(t, err)
+		}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			installFakeClock(t)
+			installFakeGPIO(t, newMockPin(nil, 0), newMockPin(wave, 1))
+			// Closing GPIO closes every pin opened through embd
+			gpioOpened := true
+			fakeClose := closeGPIO
+			closeGPIO = func() error {
+				gpioOpened = false
+				return fakeClose()
+			}
+			// Application initialize GPIO and open pin of relay
+			must(t, initGPIO())
+			relay, err := newDigitalPin(17)
+			must(t, err)
+			test.run(t)
+			if gpioOpened != test.survives {
+				t.Errorf("other pin survived: %v, want %v", gpioOpened,
+					test.survives)
+			}
+			if n := relay.(*mockPin).count("Close"); n != 0 {
+				t.Errorf("other pin closed %d times", n)
+			}
+		})
+	}
+}
+
+func TestSensorFromPinKeepPinOpened(t *testing.T) {
+	pin := newMockPin(nil, 1)
+	fake := installFakeGPIO(t)
+	sensor, err := NewSensorFromPin(DHT22, pin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sensor.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if pin.count("Close") != 0 || fake.inits != 0 || fake.closes != 0 {
+		t.Errorf("pin closed %d times, GPIO initialized %d times, "+
+			"closed %d times, want none", pin.count("Close"), fake.inits,
+			fake.closes)
+	}
+	if pin.dir != embd.In {
+		t.Errorf("line left driven")
+	}
+}
+
+func TestSensorReopenPin(t *testing.T) {
+	injected := errors.New("injected")
+	bad := newMockPin(nil, 1)
+	bad.fail["Write"] = injected
+	good := newMockPin(nil, 1)
+	good.n = bad.n
+	fake := installFakeGPIO(t, bad, good)
+	sensor, err := NewSensor(DHT22, bad.N(), quickOptions()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Capture fails on bad pin and is repeated on reopened one
+	if _, err := sensor.Read(); !errors.Is(err, ErrNoResponse) {
+		t.Errorf("got %v, want %v", err, ErrNoResponse)
+	}
+	if err := sensor.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if fake.opened != 2 || bad.count("Close") != 1 || good.count("Close") != 1 {
+		t.Errorf("opened %d pins, closed bad %d times, good %d times, "+
+			"want 2, 1, 1", fake.opened, bad.count("Close"),
+			good.count("Close"))
+	}
+	if fake.closes != 1 {
+		t.Errorf("GPIO closed %d times, want 1", fake.closes)
+	}
+}
+
+func TestRetrySchedule(t *testing.T) {
+	ms := time.Millisecond
+	tests := []struct {
+		name       string
+		sensorType SensorType
+		opts       []Option
+		want       []time.Duration
+	}{
+		{"DHT11 default", DHT11, []Option{WithRetries(2)},
+			[]time.Duration{time.Second, time.Second}},
+		{"DHT22 default", DHT22, []Option{WithRetries(2)},
+			[]time.Duration{2 * time.Second, 2 * time.Second}},
+		{"backoff", DHT22, []Option{WithRetries(5),
+			WithRetryDelay(100 * ms), WithRetryBackoff(2, 500*ms)},
+			[]time.Duration{100 * ms, 200 * ms, 400 * ms, 500 * ms, 500 * ms}},
+		{"delay above maximum", DHT22, []Option{WithRetries(2),
+			WithRetryBackoff(1.5, time.Second)},
+			[]time.Duration{time.Second, time.Second}},
+		{"no retries", DHT22, nil, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			clock := installFakeClock(t)
+			pin := newMockPin(nil, 1)
+			opts := append([]Option{WithStartHold(0)}, test.opts...)
+			sensor, err := NewSensorFromPin(test.sensorType, pin, opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, retried, err := sensor.readWithRetry(context.Background())
+			if !errors.Is(err, ErrNoResponse) {
+				t.Fatalf("got %v, want %v", err, ErrNoResponse)
+			}
+			if retried != len(test.want) {
+				t.Errorf("retried %d times, want %d", retried, len(test.want))
+			}
+			if fmt.Sprint(clock.pauses) != fmt.Sprint(test.want) {
+				t.Errorf("paused %v, want %v", clock.pauses, test.want)
+			}
+			var retryErr *RetryError
+			if errors.As(err, &retryErr) &&
+				len(retryErr.Attempts) != len(test.want)+1 {
+				t.Errorf("%d attempts recorded, want %d",
+					len(retryErr.Attempts), len(test.want)+1)
+			}
+		})
+	}
+}
+
+func TestNewCachedSensorTTL(t *testing.T) {
+	for _, ttl := range []time.Duration{0, -time.Second} {
+		cached, err := NewCachedSensor(&Sensor{}, ttl)
+		if !errors.Is(err, ErrInvalidOption) || cached != nil {
+			t.Errorf("ttl %v: got %v, %v, want ErrInvalidOption", ttl, cached, err)
+		}
+	}
+	if _, err := NewCachedSensor(&Sensor{}, time.Second); err != nil {
+		t.Errorf("ttl 1s: %v", err)
+	}
+}
+
+// Pulses captured from real sensor, played back through mock pin,
+// give raw bytes of 45.2% and 21.3°C.
+func TestReadRawCapturedTrace(t *testing.T) {
+	pulses := loadPulses(t, "dht22.txt")
+	wave := make([]level, len(pulses))
+	for i, p := range pulses {
+		wave[i] = level{int(p.Value), p.Duration}
+	}
+	sensor, err := NewSensorFromPin(DHT22, newMockPin(wave, 1),
+		quickOptions()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := sensor.ReadRaw()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := dht22Bytes(452, 213); b != want {
+		t.Errorf("got % x, want % x", b, want)
+	}
+	humidity := uint16(b[0])<<8 | uint16(b[1])
+	temperature := uint16(b[2])<<8 | uint16(b[3])
+	if humidity != 452 || temperature != 213 {
+		t.Errorf("got %d and %d tenths, want 452 and 213", humidity,
+			temperature)
+	}
+}
+
+// Pin holding the first start signal until released,
+// so sensor read stays in flight meanwhile.
+type heldPin struct {
+	*mockPin
+	once    sync.Once
+	started chan struct{}
+	release chan struct{}
+}
+
+func (this *heldPin) Write(val int) error {
+	this.once.Do(func() {
+		close(this.started)
+		<-this.release
+	})
+	return this.mockPin.Write(val)
+}
+
+func TestCachedSensorSharesRead(t *testing.T) {
+	const callers = 10
+	pin := &heldPin{mockPin: newMockPin(responseWave(dht22Bytes(452, 213)), 1),
+		started: make(chan struct{}), release: make(chan struct{})}
+	sensor, err := NewSensorFromPin(DHT22, pin, quickOptions()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cached, err := NewCachedSensor(sensor, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	readings := make([]Reading, callers)
+	errs := make([]error, callers)
+	var wg sync.WaitGroup
+	read := func(i int) {
+		defer wg.Done()
+		readings[i], errs[i] = cached.Read()
+	}
+	wg.Add(callers)
+	go read(0)
+	<-pin.started
+	for i := 1; i < callers; i++ {
+		go read(i)
+	}
+	// Let other callers join the read in flight. Late callers get
+	// cached reading instead, which doesn't read sensor either.
+	time.Sleep(10 * time.Millisecond)
+	close(pin.release)
+	wg.Wait()
+	for i := range readings {
+		if errs[i] != nil {
+			t.Fatalf("caller %d: %v", i, errs[i])
+		}
+		if readings[i].Temperature != 21.3 || readings[i].Humidity != 45.2 {
+			t.Errorf("caller %d: got %v %v, want 21.3°C 45.2%%", i,
+				readings[i].Temperature, readings[i].Humidity)
+		}
+	}
+	if captures := pin.count("Write") / 2; captures != 1 {
+		t.Errorf("sensor captured %d times, want 1", captures)
+	}
+}
+
+func TestCachedSensorExpired(t *testing.T) {
+	pin := newMockPin(responseWave(dht22Bytes(452, 213)), 1)
+	sensor, err := NewSensorFromPin(DHT22, pin, quickOptions()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cached, err := NewCachedSensor(sensor, time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cached.Read(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(2 * time.Millisecond)
+	// Sensor can't be read within minimum interval, so expired reading
+	// comes along with error
+	reading, err := cached.Read()
+	if !errors.Is(err, ErrTooSoon) {
+		t.Errorf("got %v, want %v", err, ErrTooSoon)
+	}
+	if !reading.Stale || reading.Age < time.Millisecond ||
+		reading.Temperature != 21.3 || reading.Humidity != 45.2 {
+		t.Errorf("got %+v, want expired reading of 21.3°C 45.2%%", reading)
+	}
+	if captures := pin.count("Write") / 2; captures != 1 {
+		t.Errorf("sensor captured %d times, want 1", captures)
+	}
+}
+
+func TestDoubleRead(t *testing.T) {
+	clock := installFakeClock(t)
+	pin := newMockPin(responseWave(dht22Bytes(452, 213)), 1)
+	sensor, err := NewSensorFromPin(DHT22, pin,
+		quickOptions(WithDoubleRead(), WithMinIntervalWait())...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	captures := func() int {
+		return pin.count("Write") / 2
+	}
+
+	// After idle sensor is read twice, minimum interval apart
+	reading, err := sensor.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if captures() != 2 || reading.Discarded == nil ||
+		reading.Discarded.Temperature != 21.3 {
+		t.Errorf("%d captures, discarded %+v, want 2 captures and "+
+			"discarded reading", captures(), reading.Discarded)
+	}
+	if fmt.Sprint(clock.pauses) != fmt.Sprint([]time.Duration{2 * time.Second}) {
+		t.Errorf("paused %v, want minimum interval", clock.pauses)
+	}
+
+	// Sensor read recently isn't primed again
+	reading, err = sensor.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if captures() != 3 || reading.Discarded != nil {
+		t.Errorf("%d captures, discarded %+v, want single capture",
+			captures(), reading.Discarded)
+	}
+}
+
+func TestDoubleReadFailure(t *testing.T) {
+	clock := installFakeClock(t)
+	pin := newMockPin(nil, 1)
+	sensor, err := NewSensorFromPin(DHT22, pin,
+		quickOptions(WithDoubleRead(), WithRetries(1))...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Failed extra read is neither retried nor counted as attempt
+	_, err = sensor.Read()
+	var retryErr *RetryError
+	if !errors.As(err, &retryErr) || len(retryErr.Attempts) != 2 {
+		t.Fatalf("got %v, want 2 failed attempts", err)
+	}
+	if n := pin.count("Write") / 2; n != 3 {
+		t.Errorf("%d captures, want 3", n)
+	}
+	want := []time.Duration{2 * time.Second, time.Millisecond}
+	if fmt.Sprint(clock.pauses) != fmt.Sprint(want) {
+		t.Errorf("paused %v, want %v", clock.pauses, want)
+	}
+}
@@ -0,0 +1,45 @@
+//go:build go1.21
+
+package dht
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// Adapter of structured logger from log/slog to FieldLogger interface.
+type slogLogger struct {
+	l *slog.Logger
+}
+
+// Wrap slog logger, so diagnostics come out as records with attributes:
+//
+//	dht.SetLogger(dht.NewSlogLogger(slog.Default()))
+func NewSlogLogger(l *slog.Logger) FieldLogger {
+	return &slogLogger{l: l}
+}
+
+func (this *slogLogger) Debugf(format string, args ...interface{}) {
+	if this.l.Enabled(context.Background(), slog.LevelDebug) {
+		this.l.Debug(fmt.Sprintf(format, args...))
+	}
+}
+
+func (this *slogLogger) DebugEnabled() bool {
+	return this.l.Enabled(context.Background(), slog.LevelDebug)
+}
+
+func (this *slogLogger) Warningf(format string, args ...interface{}) {
+	this.l.Warn(fmt.Sprintf(format, args...))
+}
+
+// Values, such as pulses, are rendered by handler only
+// if it's enabled for debug level.
+func (this *slogLogger) DebugFields(msg string, keyvals ...interface{}) {
+	this.l.Debug(msg, keyvals...)
+}
+
+func (this *slogLogger) WarningFields(msg string, keyvals ...interface{}) {
+	this.l.Warn(msg, keyvals...)
+}
@@ -0,0 +1,255 @@
+package dht
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Outcome of single read made by Soak.
+type SoakOutcome struct {
+	Time time.Time `json:"time"`
+	// Failure category, empty if read succeeded.
+	Failure     string  `json:"failure,omitempty"`
+	Temperature float32 `json:"temperature,omitempty"`
+	Humidity    float32 `json:"humidity,omitempty"`
+	// One minute load average, negative if unknown.
+	Load float32 `json:"load"`
+}
+
+// Statistics of soak outcomes, either of whole run or of an hour.
+type SoakStats struct {
+	Reads    int `json:"reads"`
+	Failures int `json:"failures"`
+	// Failure count by category (see ErrorKind).
+	Kinds map[string]int `json:"kinds,omitempty"`
+	// Range of values read successfully.
+	MinTemperature float32 `json:"min_temperature"`
+	MaxTemperature float32 `json:"max_temperature"`
+	MinHumidity    float32 `json:"min_humidity"`
+	MaxHumidity    float32 `json:"max_humidity"`
+	// Mean load average of successful and failed reads,
+	// negative if unknown.
+	LoadOK     float32 `json:"load_ok"`
+	LoadFailed float32 `json:"load_failed"`
+
+	loadOK, loadFailed   float64
+	loadOKN, loadFailedN int
+}
+
+// Statistics of an hour of soak run.
+type SoakHour struct {
+	Start time.Time `json:"start"`
+	SoakStats
+}
+
+// Summary of soak run. Memory doesn't depend on number of reads,
+// only hourly breakdown grows with run duration.
+type SoakReport struct {
+	Sensor SensorType `json:"sensor"`
+	Pin    int        `json:"pin"`
+	Start  time.Time  `json:"start"`
+	End    time.Time  `json:"end"`
+	// True, if run was stopped before its duration elapsed.
+	Interrupted bool `json:"interrupted"`
+	SoakStats
+	// Values of first and last successful read, to see drift.
+	FirstTemperature float32    `json:"first_temperature"`
+	LastTemperature  float32    `json:"last_temperature"`
+	FirstHumidity    float32    `json:"first_humidity"`
+	LastHumidity     float32    `json:"last_humidity"`
+	Hours            []SoakHour `json:"hours"`
+}
+
+// Add outcome to statistics.
+func (this *SoakStats) add(outcome SoakOutcome) {
+	this.Reads++
+	if outcome.Failure != "" {
+		this.Failures++
+		if this.Kinds == nil {
+			this.Kinds = make(map[string]int)
+		}
+		this.Kinds[outcome.Failure]++
+		if outcome.Load >= 0 {
+			this.loadFailed += float64(outcome.Load)
+			this.loadFailedN++
+		}
+	} else {
+		ok := this.Reads - this.Failures
+		t, h := outcome.Temperature, outcome.Humidity
+		if ok == 1 || t < this.MinTemperature {
+			this.MinTemperature = t
+		}
+		if ok == 1 || t > this.MaxTemperature {
+			this.MaxTemperature = t
+		}
+		if ok == 1 || h < this.MinHumidity {
+			this.MinHumidity = h
+		}
+		if ok == 1 || h > this.MaxHumidity {
+			this.MaxHumidity = h
+		}
+		if outcome.Load >= 0 {
+			this.loadOK += float64(outcome.Load)
+			this.loadOKN++
+		}
+	}
+	this.LoadOK = meanLoad(this.loadOK, this.loadOKN)
+	this.LoadFailed = meanLoad(this.loadFailed, this.loadFailedN)
+}
+
+func meanLoad(sum float64, n int) float32 {
+	if n == 0 {
+		return -1
+	}
+	return float32(sum / float64(n))
+}
+
+// Add outcome to report. Outcomes should come in time order.
+func (this *SoakReport) Add(outcome SoakOutcome) {
+	if this.Reads == 0 && this.Start.IsZero() {
+		this.Start = outcome.Time
+	}
+	this.End = outcome.Time
+	if outcome.Failure == "" {
+		if this.Reads == this.Failures {
+			this.FirstTemperature = outcome.Temperature
+			this.FirstHumidity = outcome.Humidity
+		}
+		this.LastTemperature = outcome.Temperature
+		this.LastHumidity = outcome.Humidity
+	}
+	this.SoakStats.add(outcome)
+	hour := outcome.Time.Truncate(time.Hour)
+	if n := len(this.Hours); n == 0 || !this.Hours[n-1].Start.Equal(hour) {
+		this.Hours = append(this.Hours, SoakHour{Start: hour})
+	}
+	this.Hours[len(this.Hours)-1].add(outcome)
+}
+
+// Render report as text with hourly breakdown.
+func (this SoakReport) String() string {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "Soak of %v on pin %d: %v - %v", this.Sensor, this.Pin,
+		this.Start.Format("2006-01-02 15:04:05"),
+		this.End.Format("2006-01-02 15:04:05"))
+	if this.Interrupted {
+		buf.WriteString(" (interrupted)")
+	}
+	buf.WriteByte('\n')
+	fmt.Fprintf(&buf, "Reads: %d, failed: %d (%s)%s\n", this.Reads,
+		this.Failures, successRate(this.SoakStats), formatKinds(this.Kinds))
+	if this.Reads > this.Failures {
+		fmt.Fprintf(&buf, "Temperature: %.1f..%.1f°C, drift %+.1f°C\n",
+			this.MinTemperature, this.MaxTemperature,
+			this.LastTemperature-this.FirstTemperature)
+		fmt.Fprintf(&buf, "Humidity: %.1f..%.1f%%, drift %+.1f%%\n",
+			this.MinHumidity, this.MaxHumidity,
+			this.LastHumidity-this.FirstHumidity)
+	}
+	fmt.Fprintf(&buf, "Load average: %s on success, %s on failure\n",
+		formatLoad(this.LoadOK), formatLoad(this.LoadFailed))
+	for _, hour := range this.Hours {
+		fmt.Fprintf(&buf, "%s  %5d reads %5d failed (%s)%s\n",
+			hour.Start.Format("2006-01-02 15:04"), hour.Reads, hour.Failures,
+			successRate(hour.SoakStats), formatKinds(hour.Kinds))
+	}
+	return buf.String()
+}
+
+func successRate(stats SoakStats) string {
+	if stats.Reads == 0 {
+		return "no reads"
+	}
+	return fmt.Sprintf("%.1f%% success",
+		float64(stats.Reads-stats.Failures)*100/float64(stats.Reads))
+}
+
+// Render failure counts sorted by category.
+func formatKinds(kinds map[string]int) string {
+	names := make([]string, 0, len(kinds))
+	for name := range kinds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var buf strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&buf, " %s=%d", name, kinds[name])
+	}
+	return buf.String()
+}
+
+func formatLoad(load float32) string {
+	if load < 0 {
+		return "unknown"
+	}
+	return fmt.Sprintf("%.2f", load)
+}
+
+// Return one minute load average, replaced by tests.
+var loadAverage = readLoadAverage
+
+// Read one minute load average from /proc/loadavg,
+// negative if it's unavailable.
+func readLoadAverage() float32 {
+	data, err := os.ReadFile("/proc/loadavg")
+	if err != nil {
+		return -1
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return -1
+	}
+	load, err := strconv.ParseFloat(fields[0], 32)
+	if err != nil {
+		return -1
+	}
+	return float32(load)
+}
+
+// Read sensor every minimum interval (see SensorType.MinInterval)
+// for duration and summarize outcomes. Every outcome is written
+// to w as JSON line, if w isn't nil. Once ctx is done, report
+// of reads made so far is returned along with error.
+func Soak(ctx context.Context, sensor *Sensor, duration time.Duration,
+	w io.Writer) (SoakReport, error) {
+	report := SoakReport{Sensor: sensor.Type(), Pin: sensor.Pin(),
+		Start: time.Now()}
+	var enc *json.Encoder
+	if w != nil {
+		enc = json.NewEncoder(w)
+	}
+	deadline := report.Start.Add(duration)
+	for time.Now().Before(deadline) {
+		reading, err := sensor.ReadContext(ctx)
+		if ctx.Err() != nil {
+			report.Interrupted = true
+			return report, contextError(ctx)
+		}
+		outcome := SoakOutcome{Time: time.Now(), Load: loadAverage()}
+		if err != nil {
+			outcome.Failure = Kind(err).String()
+		} else {
+			outcome.Temperature = reading.Temperature.Celsius()
+			outcome.Humidity = reading.Humidity.Percent()
+		}
+		report.Add(outcome)
+		if enc != nil {
+			if err := enc.Encode(outcome); err != nil {
+				return report, err
+			}
+		}
+		// Failed read doesn't count for minimum interval, so wait anyway
+		if err := pause(ctx, sensor.cfg.minInterval); err != nil {
+			report.Interrupted = true
+			return report, err
+		}
+	}
+	return report, nil
+}
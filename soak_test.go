@@ -0,0 +1,181 @@
+package dht
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSoakReport(t *testing.T) {
+	start := time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)
+	ok := func(minutes int, temp, hum, load float32) SoakOutcome {
+		return SoakOutcome{Time: start.Add(time.Duration(minutes) * time.Minute),
+			Temperature: temp, Humidity: hum, Load: load}
+	}
+	failed := func(minutes int, kind ErrorKind, load float32) SoakOutcome {
+		return SoakOutcome{Time: start.Add(time.Duration(minutes) * time.Minute),
+			Failure: kind.String(), Load: load}
+	}
+	report := SoakReport{Sensor: DHT22, Pin: 4}
+	for _, outcome := range []SoakOutcome{
+		failed(0, KindNoResponse, 3),
+		ok(10, 21.3, 45.2, 0.5),
+		ok(20, 20.9, 46, 0.5),
+		failed(40, KindChecksum, 2),
+		ok(60, 22.1, 44.1, -1),
+		failed(70, KindChecksum, -1),
+		ok(90, 21.8, 44.5, 1.5),
+	} {
+		report.Add(outcome)
+	}
+
+	if report.Reads != 7 || report.Failures != 3 ||
+		report.Kinds["checksum"] != 2 || report.Kinds["no-response"] != 1 {
+		t.Errorf("got %d reads, %d failures %v", report.Reads, report.Failures,
+			report.Kinds)
+	}
+	if !report.Start.Equal(start) || !report.End.Equal(start.Add(90*time.Minute)) {
+		t.Errorf("run %v - %v", report.Start, report.End)
+	}
+	if report.MinTemperature != 20.9 || report.MaxTemperature != 22.1 ||
+		report.MinHumidity != 44.1 || report.MaxHumidity != 46 {
+		t.Errorf("ranges %+v", report.SoakStats)
+	}
+	if report.FirstTemperature != 21.3 || report.LastTemperature != 21.8 ||
+		report.FirstHumidity != 45.2 || report.LastHumidity != 44.5 {
+		t.Errorf("drift %v..%v, %v..%v", report.FirstTemperature,
+			report.LastTemperature, report.FirstHumidity, report.LastHumidity)
+	}
+	// Unknown load average is ignored
+	if !near(report.LoadOK, 2.5/3) || report.LoadFailed != 2.5 {
+		t.Errorf("load %v on success, %v on failure", report.LoadOK,
+			report.LoadFailed)
+	}
+	if len(report.Hours) != 3 {
+		t.Fatalf("got %d hours, want 3", len(report.Hours))
+	}
+	for i, want := range []struct{ reads, failures int }{{3, 1}, {3, 2}, {1, 0}} {
+		hour := report.Hours[i]
+		if !hour.Start.Equal(time.Date(2024, 5, 1, 10+i, 0, 0, 0, time.UTC)) ||
+			hour.Reads != want.reads || hour.Failures != want.failures {
+			t.Errorf("hour %d: %v %d reads %d failed, want %d %d", i,
+				hour.Start, hour.Reads, hour.Failures, want.reads, want.failures)
+		}
+	}
+	if hour := report.Hours[2]; hour.LoadFailed != -1 || hour.LoadOK != 1.5 {
+		t.Errorf("last hour load %v, %v", hour.LoadOK, hour.LoadFailed)
+	}
+
+	text := report.String()
+	for _, want := range []string{"Reads: 7, failed: 3 (57.1% success) " +
+		"checksum=2 no-response=1", "drift +0.5°C", "drift -0.7%",
+		"2024-05-01 11:00      3 reads     2 failed (33.3% success) checksum=2"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("report doesn't contain %q:\n%s", want, text)
+		}
+	}
+	data, err := json.Marshal(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var loaded SoakReport
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Reads != 7 || len(loaded.Hours) != 3 ||
+		loaded.Hours[1].Kinds["checksum"] != 2 {
+		t.Errorf("JSON %s", data)
+	}
+}
+
+// Replace load average with constant value until test is over.
+func installLoadAverage(t *testing.T, load float32) {
+	save := loadAverage
+	t.Cleanup(func() {
+		loadAverage = save
+	})
+	loadAverage = func() float32 {
+		return load
+	}
+}
+
+func TestSoak(t *testing.T) {
+	clock := installFakeClock(t)
+	installLoadAverage(t, 0.25)
+	pin := newMockPin(responseWave(dht22Bytes(452, 213)), 1)
+	sensor, err := NewSensorFromPin(DHT22, pin,
+		quickOptions(WithMinIntervalWait())...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var log bytes.Buffer
+	report, err := Soak(context.Background(), sensor, 30*time.Millisecond, &log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(log.String()), "\n")
+	if report.Reads == 0 || report.Failures != 0 || report.Interrupted ||
+		len(lines) != report.Reads {
+		t.Fatalf("%d reads, %d failed, %d outcomes logged", report.Reads,
+			report.Failures, len(lines))
+	}
+	var outcome SoakOutcome
+	if err := json.Unmarshal([]byte(lines[0]), &outcome); err != nil {
+		t.Fatal(err)
+	}
+	if outcome.Temperature != 21.3 || outcome.Humidity != 45.2 ||
+		outcome.Load != 0.25 || outcome.Failure != "" {
+		t.Errorf("logged %+v", outcome)
+	}
+	// Reads are made every minimum interval
+	for _, d := range clock.pauses {
+		if d > 2*time.Second {
+			t.Errorf("paused %v, want at most minimum interval", d)
+		}
+	}
+	if n := len(clock.pauses); n < report.Reads {
+		t.Errorf("paused %d times for %d reads", n, report.Reads)
+	}
+}
+
+// Writer cancelling context after n writes.
+type cancellingWriter struct {
+	n      int
+	cancel context.CancelFunc
+}
+
+func (this *cancellingWriter) Write(p []byte) (int, error) {
+	this.n--
+	if this.n == 0 {
+		this.cancel()
+	}
+	return len(p), nil
+}
+
+func TestSoakInterrupted(t *testing.T) {
+	installFakeClock(t)
+	installLoadAverage(t, -1)
+	sensor, err := NewSensorFromPin(DHT22, newMockPin(nil, 1),
+		quickOptions(WithMinIntervalWait())...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	report, err := Soak(ctx, sensor, time.Hour,
+		&cancellingWriter{n: 3, cancel: cancel})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want %v", err, context.Canceled)
+	}
+	if !report.Interrupted || report.Reads != 3 ||
+		report.Kinds["no-response"] != 3 || report.LoadFailed != -1 {
+		t.Errorf("got %+v", report)
+	}
+	if !strings.Contains(report.String(), "(interrupted)") {
+		t.Errorf("report doesn't tell it's interrupted:\n%s", report)
+	}
+}
@@ -0,0 +1,130 @@
+package dht
+
+import (
+	"time"
+)
+
+// Sensor characteristics according to datasheet.
+type sensorSpec struct {
+	minTemperature float32
+	maxTemperature float32
+	minHumidity    float32
+	maxHumidity    float32
+	// Temperature resolution in Celsius and humidity one in percent.
+	temperatureResolution float32
+	humidityResolution    float32
+	minInterval           time.Duration
+	// How long host pulls line low to wake sensor up.
+	startLow time.Duration
+}
+
+var sensorSpecs = map[SensorType]sensorSpec{
+	DHT11: {minTemperature: 0, maxTemperature: 50,
+		minHumidity: 20, maxHumidity: 90,
+		temperatureResolution: 0.1, humidityResolution: 0.1,
+		minInterval: time.Second, startLow: 18 * time.Millisecond},
+	DHT22: {minTemperature: -40, maxTemperature: 80,
+		minHumidity: 0, maxHumidity: 100,
+		temperatureResolution: 0.1, humidityResolution: 0.1,
+		minInterval: 2 * time.Second, startLow: 2 * time.Millisecond},
+}
+
+// Return datasheet characteristics of sensor type.
+func (this SensorType) spec() (sensorSpec, error) {
+	spec, ok := sensorSpecs[this]
+	if !ok {
+		return sensorSpec{}, &UnknownSensorTypeError{Type: this}
+	}
+	return spec, nil
+}
+
+// Return temperature range in Celsius sensor is able to measure:
+// 0..50 for DHT11 and -40..80 for DHT22.
+func (this SensorType) TemperatureRange() (min, max float32, err error) {
+	spec, err := this.spec()
+	return spec.minTemperature, spec.maxTemperature, err
+}
+
+// Return relative humidity range in percent sensor is able to measure:
+// 20..90 for DHT11 and 0..100 for DHT22.
+func (this SensorType) HumidityRange() (min, max float32, err error) {
+	spec, err := this.spec()
+	return spec.minHumidity, spec.maxHumidity, err
+}
+
+// Return smallest step of temperature (Celsius) and humidity (percent)
+// sensor report: 0.1 for both types, though older DHT11 firmware
+// report whole units only.
+func (this SensorType) Resolution() (temperature, humidity float32,
+	err error) {
+	spec, err := this.spec()
+	return spec.temperatureResolution, spec.humidityResolution, err
+}
+
+// Minimum interval between reads according to datasheet:
+// 1 second for DHT11 and 2 seconds for DHT22.
+func (this SensorType) MinInterval() (time.Duration, error) {
+	spec, err := this.spec()
+	return spec.minInterval, err
+}
+
+// Return OutOfRangeError carrying reading,
+// if value doesn't fit into [min, max].
+func checkRange(reading Reading, field string, value, min, max float32) error {
+	if value < min {
+		return &OutOfRangeError{Reading: reading, Field: field, Value: value,
+			Limit: min}
+	}
+	if value > max {
+		return &OutOfRangeError{Reading: reading, Field: field, Value: value,
+			Limit: max}
+	}
+	return nil
+}
+
+// Check temperature and humidity against measurement range of sensor
+// type reading was decoded for. Return OutOfSpecError for value
+// sensor can't physically report, bounds are inclusive.
+func (this Reading) Validate() error {
+	errs, err := this.specErrors()
+	if err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+// Check every field against measurement range,
+// unlike Validate, which stop on first offending one.
+func (this Reading) specErrors() ([]*OutOfSpecError, error) {
+	spec, err := this.Sensor.spec()
+	if err != nil {
+		return nil, err
+	}
+	var errs []*OutOfSpecError
+	t := this.Temperature.Celsius()
+	if t < spec.minTemperature || t > spec.maxTemperature {
+		errs = append(errs, &OutOfSpecError{Reading: this, Sensor: this.Sensor,
+			Field: "temperature", Value: t, Min: spec.minTemperature, Max: spec.maxTemperature})
+	}
+	h := this.Humidity.Percent()
+	if h < spec.minHumidity || h > spec.maxHumidity {
+		errs = append(errs, &OutOfSpecError{Reading: this, Sensor: this.Sensor,
+			Field: "humidity", Value: h, Min: spec.minHumidity, Max: spec.maxHumidity})
+	}
+	return errs, nil
+}
+
+// Mark fields out of measurement range invalid.
+func (this *Reading) markOutOfSpec() {
+	errs, _ := this.specErrors()
+	for _, err := range errs {
+		if err.Field == "temperature" {
+			this.temperatureBad = true
+		} else {
+			this.humidityBad = true
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package dht
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Pulses captured from sensor along with read outcome, so failed read
+// could be decoded again later, for instance with different options.
+// Trace is saved as JSON, pulses in the format of Pulses.String.
+type Trace struct {
+	Sensor SensorType `json:"sensor"`
+	Pin    int        `json:"pin"`
+	// Time when pulses were captured.
+	Time   time.Time `json:"time"`
+	Pulses Pulses    `json:"pulses"`
+	// Error of capture or decoding, empty if read succeeded.
+	Error string `json:"error,omitempty"`
+}
+
+// Activate sensor and return captured pulses without decoding them.
+// Minimum interval between reads is respected as Read does.
+func (this *Sensor) Capture() (Pulses, error) {
+	return this.CaptureContext(context.Background())
+}
+
+// Same as Capture, but stop as soon as ctx is done.
+func (this *Sensor) CaptureContext(ctx context.Context) (Pulses, error) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	if err := this.waitInterval(ctx); err != nil {
+		return nil, err
+	}
+	pulses, _, err := this.capture(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Sensor responded, so it can't be read again at once anyway
+	recordRead(this.pin, time.Now())
+	return pulses, nil
+}
+
+// Capture and decode pulses, keeping them in trace whatever outcome is.
+// Return reading along with error of capture or decoding, if any.
+func (this *Sensor) Record(ctx context.Context) (Trace, Reading, error) {
+	trace := Trace{Sensor: this.sensorType, Pin: this.pin, Time: time.Now()}
+	pulses, err := this.CaptureContext(ctx)
+	var reading Reading
+	if err == nil {
+		trace.Pulses = pulses
+		reading, err = decodeChecked(this.sensorType, pulses, this.cfg)
+		reading.Pin = this.pin
+	}
+	if err != nil {
+		trace.Error = err.Error()
+		return trace, Reading{}, err
+	}
+	return trace, reading, nil
+}
+
+// Decode pulses of trace with options, such as WithNegativeEncoding
+// or WithStrictFrame, as they would be decoded by live read.
+func (this Trace) Decode(opts ...Option) (Reading, error) {
+	cfg, err := newConfig(this.Sensor, opts)
+	if err != nil {
+		return Reading{}, err
+	}
+	reading, err := decodeChecked(this.Sensor, this.Pulses, cfg)
+	if err != nil {
+		return Reading{}, err
+	}
+	reading.Pin = this.Pin
+	reading.Time = this.Time
+	return reading, nil
+}
+
+// Save trace to file in JSON format.
+func (this Trace) Save(path string) error {
+	data, err := json.MarshalIndent(this, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Load trace saved by Trace.Save.
+func LoadTrace(path string) (Trace, error) {
+	var trace Trace
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return trace, err
+	}
+	if err := json.Unmarshal(data, &trace); err != nil {
+		return trace, fmt.Errorf("Can't load trace %s: %w", path, err)
+	}
+	return trace, nil
+}
+
+// Parse pulses rendered by Pulses.String: "L54 H70 L50 H24 ...".
+func ParsePulses(s string) (Pulses, error) {
+	fields := strings.Fields(s)
+	pulses := make(Pulses, len(fields))
+	for i, field := range fields {
+		us, err := strconv.Atoi(field[1:])
+		if err != nil || us < 0 || field[0] != 'L' && field[0] != 'H' {
+			return nil, fmt.Errorf("Bad pulse %q at index %d", field, i)
+		}
+		pulses[i].Duration = time.Duration(us) * time.Microsecond
+		if field[0] == 'H' {
+			pulses[i].Value = 1
+		}
+	}
+	return pulses, nil
+}
+
+// Implement encoding.TextMarshaler interface, see Pulses.String.
+func (this Pulses) MarshalText() ([]byte, error) {
+	return []byte(this.String()), nil
+}
+
+// Implement encoding.TextUnmarshaler interface, see ParsePulses.
+func (this *Pulses) UnmarshalText(text []byte) error {
+	pulses, err := ParsePulses(string(text))
+	if err != nil {
+		return err
+	}
+	*this = pulses
+	return nil
+}
@@ -0,0 +1,89 @@
+package dht
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestTraceRecordReplay(t *testing.T) {
+	badChecksum := dht22Bytes(452, 213)
+	badChecksum[4]++
+	// Clone sending -0.8°C in two's complement
+	twos := [5]byte{0x01, 0xC4, 0xFF, 0xF8}
+	twos[4] = twos[0] + twos[1] + twos[2] + twos[3]
+	tests := []struct {
+		name string
+		b    [5]byte
+		// Error of live read.
+		err error
+		// Options and expected temperature of replay.
+		opts []Option
+		want float32
+	}{
+		{"good", dht22Bytes(452, 213), nil, nil, 21.3},
+		{"checksum", badChecksum, ErrChecksum, nil, 0},
+		{"clone", twos, ErrOutOfSpec,
+			[]Option{WithNegativeEncoding(TwosComplement)}, -0.8},
+	}
+	dir := t.TempDir()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pin := newMockPin(responseWave(test.b), 1)
+			sensor, err := NewSensorFromPin(DHT22, pin,
+				quickOptions(WithStrictRange())...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			trace, reading, err := sensor.Record(context.Background())
+			if !errors.Is(err, test.err) {
+				t.Fatalf("got %v, want %v", err, test.err)
+			}
+			if err == nil && reading.Temperature != 21.3 {
+				t.Errorf("got %v, want 21.3°C", reading.Temperature)
+			}
+			if (trace.Error != "") != (test.err != nil) ||
+				len(trace.Pulses) < 80 {
+				t.Errorf("trace error %q with %d pulses", trace.Error,
+					len(trace.Pulses))
+			}
+
+			path := filepath.Join(dir, test.name+".json")
+			if err := trace.Save(path); err != nil {
+				t.Fatal(err)
+			}
+			loaded, err := LoadTrace(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if loaded.Sensor != DHT22 || loaded.Pin != pin.N() ||
+				loaded.Error != trace.Error || !loaded.Time.Equal(trace.Time) ||
+				loaded.Pulses.String() != trace.Pulses.String() {
+				t.Errorf("loaded %+v, want %+v", loaded, trace)
+			}
+			reading, err = loaded.Decode(test.opts...)
+			if test.want == 0 {
+				if !errors.Is(err, test.err) {
+					t.Errorf("replay: got %v, want %v", err, test.err)
+				}
+				return
+			}
+			if err != nil || reading.Temperature != Temperature(test.want) {
+				t.Errorf("replay: got %v, %v, want %v°C", reading.Temperature,
+					err, test.want)
+			}
+		})
+	}
+}
+
+func TestParsePulsesErrors(t *testing.T) {
+	for _, s := range []string{"X54", "L", "H-5", "L54 Hx"} {
+		if _, err := ParsePulses(s); err == nil {
+			t.Errorf("%q parsed without error", s)
+		}
+	}
+	if pulses, err := ParsePulses(""); err != nil || len(pulses) != 0 {
+		t.Errorf("empty: got %v, %v", pulses, err)
+	}
+}
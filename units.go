@@ -0,0 +1,52 @@
+package dht
+
+import (
+	"fmt"
+)
+
+// Temperature in Celsius.
+type Temperature float32
+
+// Return temperature in Celsius.
+func (this Temperature) Celsius() float32 {
+	return float32(this)
+}
+
+// Return temperature in Fahrenheit.
+func (this Temperature) Fahrenheit() float32 {
+	return CelsiusToFahrenheit(float32(this))
+}
+
+// Return temperature in Kelvin.
+func (this Temperature) Kelvin() float32 {
+	return CelsiusToKelvin(float32(this))
+}
+
+// Convert temperature returned by ReadDHTxx and friends to Fahrenheit.
+// Calculation is made in float64 to avoid float32 rounding errors.
+func CelsiusToFahrenheit(celsius float32) float32 {
+	return float32(float64(celsius)*9/5 + 32)
+}
+
+// Convert temperature in Celsius to Kelvin.
+func CelsiusToKelvin(celsius float32) float32 {
+	return float32(float64(celsius) + 273.15)
+}
+
+// Implement Stringer interface: "21.3°C".
+func (this Temperature) String() string {
+	return fmt.Sprintf("%.1f°C", float32(this))
+}
+
+// Relative humidity in percent.
+type Humidity float32
+
+// Return humidity in percent.
+func (this Humidity) Percent() float32 {
+	return float32(this)
+}
+
+// Implement Stringer interface: "45.0%".
+func (this Humidity) String() string {
+	return fmt.Sprintf("%.1f%%", float32(this))
+}
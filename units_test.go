@@ -0,0 +1,60 @@
+package dht
+
+import (
+	"testing"
+)
+
+func TestTemperatureConversions(t *testing.T) {
+	tests := []struct {
+		celsius    Temperature
+		fahrenheit float32
+		kelvin     float32
+	}{
+		{0, 32, 273.15},
+		{100, 212, 373.15},
+		{-40, -40, 233.15},
+		{-10.5, 13.1, 262.65},
+		{21.3, 70.34, 294.45},
+	}
+	for _, test := range tests {
+		// Conversion made in float64 gives nearest float32,
+		// so values compare exactly
+		if got := test.celsius.Fahrenheit(); got != test.fahrenheit {
+			t.Errorf("%v: got %v°F, want %v°F", test.celsius, got,
+				test.fahrenheit)
+		}
+		if got := test.celsius.Kelvin(); got != test.kelvin {
+			t.Errorf("%v: got %vK, want %vK", test.celsius, got, test.kelvin)
+		}
+		reading := Reading{Temperature: test.celsius}
+		if reading.TemperatureF() != test.fahrenheit ||
+			reading.TemperatureK() != test.kelvin {
+			t.Errorf("%v: reading got %v°F %vK, want %v°F %vK", test.celsius,
+				reading.TemperatureF(), reading.TemperatureK(),
+				test.fahrenheit, test.kelvin)
+		}
+		if test.celsius.Celsius() != float32(test.celsius) {
+			t.Errorf("%v: got %v°C", test.celsius, test.celsius.Celsius())
+		}
+	}
+}
+
+func TestUnitsString(t *testing.T) {
+	tests := []struct {
+		value interface{ String() string }
+		want  string
+	}{
+		{Temperature(21.3), "21.3°C"},
+		{Temperature(-3.5), "-3.5°C"},
+		{Temperature(0), "0.0°C"},
+		{Temperature(21.25), "21.2°C"},
+		{Humidity(45), "45.0%"},
+		{Humidity(100), "100.0%"},
+		{Humidity(45.26), "45.3%"},
+	}
+	for _, test := range tests {
+		if got := test.value.String(); got != test.want {
+			t.Errorf("got %q, want %q", got, test.want)
+		}
+	}
+}